@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+const auditActionAccountDeleted = "account_deleted"
+
+type ExportedUser struct {
+	ID             int64      `json:"id"`
+	MembershipID   string     `json:"membershipId"`
+	MembershipType int64      `json:"membershipType"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+}
+
+// ExportedTokens describes the stored Bungie tokens without the secrets.
+type ExportedTokens struct {
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type ExportedLeaderboard struct {
+	DisplayName  string                `json:"displayName"`
+	TotalPoints  float64               `json:"totalPoints"`
+	MaxPoints    float64               `json:"maxPoints"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+	BucketScores []ExportedBucketScore `json:"bucketScores"`
+}
+
+type ExportedBucketScore struct {
+	BucketName    string  `json:"bucketName"`
+	CurrentPoints float64 `json:"currentPoints"`
+	MaxPoints     float64 `json:"maxPoints"`
+	Completion    float64 `json:"completion"`
+}
+
+type ExportedRatingSnapshot struct {
+	TotalPoints float64   `json:"totalPoints"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// AccountExport is everything stored about a user.
+type AccountExport struct {
+	ExportedAt  time.Time                `json:"exportedAt"`
+	User        ExportedUser             `json:"user"`
+	Tokens      *ExportedTokens          `json:"tokens,omitempty"`
+	Leaderboard *ExportedLeaderboard     `json:"leaderboard,omitempty"` // Only when opted in
+	Snapshots   []ExportedRatingSnapshot `json:"snapshots"`
+	Goals       []Goal                   `json:"goals"`
+	Preferences RatingPreferences        `json:"preferences"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// exportAccount gathers the user row and every row that depends on it.
+func (api *apiConfig) exportAccount(ctx context.Context, userID int64) (AccountExport, error) {
+	user, err := api.Store.GetUser(ctx, userID)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	export := AccountExport{
+		ExportedAt: time.Now().UTC(),
+		User: ExportedUser{
+			ID:             user.ID,
+			MembershipID:   user.MembershipID,
+			MembershipType: user.MembershipType,
+			CreatedAt:      nullTimePtr(user.CreatedAt),
+		},
+		Snapshots: []ExportedRatingSnapshot{},
+		Goals:     []Goal{},
+	}
+
+	tokens, err := api.Store.GetAuthTokens(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return AccountExport{}, err
+	}
+	if err == nil {
+		export.Tokens = &ExportedTokens{ExpiresAt: tokens.ExpiresAt, CreatedAt: nullTimePtr(tokens.CreatedAt)}
+	}
+
+	snapshots, err := api.Store.ListRatingSnapshots(ctx, userID)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	for _, snapshot := range snapshots {
+		export.Snapshots = append(export.Snapshots, ExportedRatingSnapshot{
+			TotalPoints: snapshot.TotalPoints,
+			CreatedAt:   snapshot.CreatedAt,
+		})
+	}
+
+	entry, err := api.Store.GetLeaderboardEntry(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return AccountExport{}, err
+	}
+	if err == nil {
+		scores, err := api.Store.ListLeaderboardBucketScores(ctx, userID)
+		if err != nil {
+			return AccountExport{}, err
+		}
+		export.Leaderboard = &ExportedLeaderboard{
+			DisplayName:  entry.DisplayName,
+			TotalPoints:  entry.TotalPoints,
+			MaxPoints:    entry.MaxPoints,
+			UpdatedAt:    entry.UpdatedAt,
+			BucketScores: []ExportedBucketScore{},
+		}
+		for _, score := range scores {
+			export.Leaderboard.BucketScores = append(export.Leaderboard.BucketScores, ExportedBucketScore{
+				BucketName:    score.BucketName,
+				CurrentPoints: score.CurrentPoints,
+				MaxPoints:     score.MaxPoints,
+				Completion:    score.Completion,
+			})
+		}
+	}
+
+	goals, err := api.Store.ListUserGoals(ctx, userID)
+	if err != nil {
+		return AccountExport{}, err
+	}
+	for _, row := range goals {
+		goal, err := toGoal(row)
+		if err != nil {
+			return AccountExport{}, err
+		}
+		export.Goals = append(export.Goals, goal)
+	}
+
+	export.Preferences, err = api.loadRatingPreferences(ctx, userID)
+	if err != nil {
+		return AccountExport{}, err
+	}
+
+	return export, nil
+}
+
+// deleteAccount removes the user and all dependent rows in one transaction
+// and records the deletion in the audit log. Bungie offers no endpoint to
+// revoke a refresh token, so revoking means deleting the only copy we hold;
+// players can also remove the application from their Bungie account.
+func (api *apiConfig) deleteAccount(ctx context.Context, userID int64) error {
+	tx, err := api.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := api.DB.WithTx(tx)
+
+	if _, err := queries.GetUser(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, deleteRows := range []func(context.Context, int64) error{
+		queries.DeleteAuthTokens,
+		queries.DeleteLeaderboardBucketScores,
+		queries.DeleteRatingSnapshots,
+		queries.DeleteLeaderboardEntry,
+		queries.DeleteUserGoals,
+		queries.DeleteUserPreferences,
+		queries.DeleteUser,
+	} {
+		if err := deleteRows(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := queries.CreateAuditLogEntry(ctx, database.CreateAuditLogEntryParams{
+		UserID:    userID,
+		Action:    auditActionAccountDeleted,
+		Detail:    "user, tokens, leaderboard entries, snapshots, goals and preferences removed",
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
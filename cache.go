@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCache is a small in-memory cache whose entries expire after a fixed TTL.
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]ttlCacheEntry[V]
+}
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		entries: make(map[string]ttlCacheEntry[V]),
+	}
+}
+
+// Get returns a cached value if it has not expired yet.
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found || time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores a value for the cache's TTL.
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.SetUntil(key, value, time.Now().Add(c.ttl))
+}
+
+// SetUntil stores a value with an explicit expiry, pruning expired entries so
+// the map does not grow unbounded.
+func (c *ttlCache[V]) SetUntil(key string, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: expiresAt}
+}
+
+// Delete drops a cached value.
+func (c *ttlCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,101 @@
+// constants/activity_profiles.go
+
+package constants
+
+type ActivityProfile struct {
+	Name          string
+	Description   string
+	BucketWeights map[string]float64 // Multiplier applied to a bucket's score for this activity
+}
+
+var ActivityProfiles = []ActivityProfile{
+	{
+		Name:        "raid-dps",
+		Description: "Raid boss damage phases",
+		BucketWeights: map[string]float64{
+			"DPS Heavy Grenade Launcher": 1.0,
+			"DPS Rocket":                 1.0,
+			"DPS Sword":                  0.8,
+			"Linear":                     0.9,
+			"Exotic DPS (Consistent)":    1.0,
+			"Exotic DPS (Total Damage)":  1.0,
+			"Exotic Special DPS (Total)": 0.9,
+			"Exotic Heavy Burst":         0.8,
+			"Exotic Debuff":              0.9,
+			"Ammoless DPS":               0.7,
+			"Energy Damage Sniper":       0.8,
+			"Kinetic Sniper":             0.6,
+			"Kinetic Rocket Sidearm":     0.5,
+			"Energy Rocket Sidearm":      0.5,
+			"Kinetic Fusion":             0.5,
+			"Energy Fusion":              0.5,
+			"Team Support Weapon":        0.6,
+			"Weaken on Demand":           0.6,
+		},
+	},
+	{
+		Name:        "gm-add-clear",
+		Description: "Grandmaster Nightfall add clear and survival",
+		BucketWeights: map[string]float64{
+			"Add Clear with Damage Resistance": 1.0,
+			"Exotic Add Clear":                 1.0,
+			"Kinetic Blind":                    0.9,
+			"Energy Blind":                     0.9,
+			"Hitscan Overload Stun":            1.0,
+			"Energy Wave-Frame":                0.8,
+			"Kinetic Wave-Frame":               0.8,
+			"Machine Gun":                      0.9,
+			"Survivability":                    0.9,
+			"Kinetic Rocket Sidearm":           0.7,
+			"Energy Rocket Sidearm":            0.7,
+			"Orb Generation":                   0.6,
+			"Glaive":                           0.6,
+			"Energy Trace":                     0.5,
+			"Exotic Energy Primary":            0.7,
+			"Exotic Kinetic Primary":           0.7,
+		},
+	},
+	{
+		Name:        "dungeon-solo",
+		Description: "Solo dungeon runs balancing survival and burst damage",
+		BucketWeights: map[string]float64{
+			"Survivability":                    1.0,
+			"Add Clear with Damage Resistance": 0.9,
+			"Exotic Add Clear":                 0.8,
+			"Kinetic Rocket Sidearm":           0.8,
+			"Energy Rocket Sidearm":            0.8,
+			"Exotic DPS (Total Damage)":        0.8,
+			"DPS Heavy Grenade Launcher":       0.8,
+			"DPS Rocket":                       0.7,
+			"Orb Generation":                   0.7,
+			"Super Generation":                 0.6,
+			"Transcendance Generation":         0.6,
+			"Kinetic One-Two Punch":            0.6,
+			"Energy One-Two Punch":             0.6,
+		},
+	},
+	{
+		Name:        "raid-support",
+		Description: "Raid roles that keep the fireteam alive and the boss weakened",
+		BucketWeights: map[string]float64{
+			"Team Support Weapon": 1.0,
+			"Exotic Debuff":       1.0,
+			"Weaken on Demand":    0.9,
+			"Orb Generation":      0.8,
+			"Super Generation":    0.7,
+			"Energy Primary":      0.6,
+			"Kinetic Primary":     0.6,
+			"Machine Gun":         0.6,
+		},
+	},
+}
+
+// FindActivityProfile returns the activity profile with the given name.
+func FindActivityProfile(name string) (ActivityProfile, bool) {
+	for _, profile := range ActivityProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return ActivityProfile{}, false
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+	"github.com/adamararcane/d2-loot-backend/internal/wishlist"
+)
+
+func main() {
+	catalogPath := flag.String("catalog", filepath.Join("..", "generate_constants", "weapons_and_perks.json"), "tier list to export")
+	outputPath := flag.String("out", "d2loot_wishlist.txt", "where to write the DIM wishlist")
+	flag.Parse()
+
+	catalog, err := generator.ReadWeaponPerkInputs(*catalogPath)
+	if err != nil {
+		log.Fatalf("Error reading tier list: %v", err)
+	}
+
+	file, err := os.Create(*outputPath)
+	if err != nil {
+		log.Fatalf("Error creating wishlist file: %v", err)
+	}
+	defer file.Close()
+
+	list := wishlist.FromCatalog(catalog)
+	if err := wishlist.Render(file, list); err != nil {
+		log.Fatalf("Error writing wishlist: %v", err)
+	}
+
+	fmt.Printf("Wrote %d wishlist rolls to %s\n", len(list.Rolls), *outputPath)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+	"github.com/adamararcane/d2-loot-backend/internal/manifest"
+	"github.com/adamararcane/d2-loot-backend/internal/wishlist"
+)
+
+// manifestItem holds the few item fields needed to resolve wishlist hashes
+type manifestItem struct {
+	DisplayProperties struct {
+		Name string `json:"name"`
+	} `json:"displayProperties"`
+	ItemType int `json:"itemType"`
+	Plug     *struct {
+		PlugCategoryIdentifier string `json:"plugCategoryIdentifier"`
+	} `json:"plug"`
+}
+
+// manifestResolver resolves wishlist hashes against the item manifest
+type manifestResolver struct {
+	items map[string]manifestItem
+}
+
+func (m manifestResolver) WeaponName(itemHash int64) (string, bool) {
+	item, found := m.items[strconv.FormatInt(itemHash, 10)]
+	if !found || item.ItemType != 3 || item.DisplayProperties.Name == "" {
+		return "", false
+	}
+	return item.DisplayProperties.Name, true
+}
+
+func (m manifestResolver) TraitName(perkHash int64) (string, bool) {
+	item, found := m.items[strconv.FormatInt(perkHash, 10)]
+	if !found || item.Plug == nil || item.Plug.PlugCategoryIdentifier != "frames" {
+		return "", false
+	}
+	return strings.TrimPrefix(item.DisplayProperties.Name, "Enhanced "), true
+}
+
+func main() {
+	wishlistPath := flag.String("wishlist", "", "path to the DIM wishlist text file (required)")
+	catalogPath := flag.String("catalog", filepath.Join("..", "generate_constants", "weapons_and_perks.json"), "tier list to merge into")
+	outputPath := flag.String("out", "", "where to write the merged tier list (defaults to -catalog)")
+	manifestPath := flag.String("manifest", filepath.Join("..", "..", "DestinyInventoryItemDefinition.json"), "item definition manifest file")
+	bucket := flag.String("bucket", "", "bucket for weapons not yet in the tier list; they are skipped when empty")
+	rank := flag.String("rank", "1", "rank for weapons not yet in the tier list")
+	overwrite := flag.Bool("overwrite", false, "replace desired perks of conflicting entries instead of only reporting them")
+	flag.Parse()
+
+	if *wishlistPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *outputPath == "" {
+		*outputPath = *catalogPath
+	}
+
+	wishlistFile, err := os.Open(*wishlistPath)
+	if err != nil {
+		log.Fatalf("Error opening wishlist: %v", err)
+	}
+	list, err := wishlist.Parse(wishlistFile)
+	wishlistFile.Close()
+	if err != nil {
+		log.Fatalf("Error parsing wishlist: %v", err)
+	}
+
+	catalog, err := generator.ReadWeaponPerkInputs(*catalogPath)
+	if err != nil {
+		log.Fatalf("Error reading tier list: %v", err)
+	}
+
+	items, err := manifest.LoadFile(*manifestPath, func(item *manifestItem) bool {
+		return item.ItemType == 3 || item.Plug != nil
+	})
+	if err != nil {
+		log.Fatalf("Error reading item manifest: %v", err)
+	}
+
+	result := wishlist.Import(list, catalog, manifestResolver{items: items}, wishlist.ImportOptions{
+		Bucket:    *bucket,
+		Rank:      *rank,
+		Overwrite: *overwrite,
+	})
+
+	for _, conflict := range result.Conflicts {
+		fmt.Printf("CONFLICT %s: tier list has [%s], wishlist has [%s]\n",
+			conflict.WeaponName, strings.Join(conflict.CatalogPerks, ", "), strings.Join(conflict.WishlistPerks, ", "))
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Printf("SKIPPED %s\n", skipped)
+	}
+
+	if err := generator.WriteWeaponPerkInputs(*outputPath, result.Weapons); err != nil {
+		log.Fatalf("Error writing tier list: %v", err)
+	}
+
+	fmt.Printf("Imported %d rolls: %d added, %d updated, %d conflicts, %d skipped. Written to %s\n",
+		len(list.Rolls), len(result.Added), len(result.Updated), len(result.Conflicts), len(result.Skipped), *outputPath)
+	if len(result.Added) > 0 || len(result.Updated) > 0 {
+		fmt.Println("Run cmd/generate_constants to regenerate weapon_data.go")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestManifestResolver(t *testing.T) {
+	var items map[string]manifestItem
+	if err := json.Unmarshal([]byte(`{
+		"1": {"displayProperties": {"name": "Fatebringer"}, "itemType": 3},
+		"2": {"displayProperties": {"name": "Helm"}, "itemType": 2},
+		"3": {"displayProperties": {"name": ""}, "itemType": 3},
+		"10": {"displayProperties": {"name": "Explosive Payload"}, "plug": {"plugCategoryIdentifier": "frames"}},
+		"11": {"displayProperties": {"name": "Enhanced Firefly"}, "plug": {"plugCategoryIdentifier": "frames"}},
+		"12": {"displayProperties": {"name": "Arrowhead Brake"}, "plug": {"plugCategoryIdentifier": "barrels"}}
+	}`), &items); err != nil {
+		t.Fatal(err)
+	}
+	resolver := manifestResolver{items: items}
+
+	weapons := []struct {
+		hash  int64
+		name  string
+		found bool
+	}{
+		{hash: 1, name: "Fatebringer", found: true},
+		{hash: 2},
+		{hash: 3},
+		{hash: 404},
+	}
+	for _, tt := range weapons {
+		name, found := resolver.WeaponName(tt.hash)
+		if name != tt.name || found != tt.found {
+			t.Errorf("WeaponName(%d) = %q, %v; want %q, %v", tt.hash, name, found, tt.name, tt.found)
+		}
+	}
+
+	traits := []struct {
+		hash  int64
+		name  string
+		found bool
+	}{
+		{hash: 10, name: "Explosive Payload", found: true},
+		{hash: 11, name: "Firefly", found: true},
+		{hash: 12},
+		{hash: 1},
+	}
+	for _, tt := range traits {
+		name, found := resolver.TraitName(tt.hash)
+		if name != tt.name || found != tt.found {
+			t.Errorf("TraitName(%d) = %q, %v; want %q, %v", tt.hash, name, found, tt.name, tt.found)
+		}
+	}
+}
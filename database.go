@@ -0,0 +1,49 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+)
+
+// defaultDevDatabaseURL is used in development when DATABASE_URL is unset.
+const defaultDevDatabaseURL = "file:d2loot.db?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+
+// openDatabase opens local file: URLs with the sqlite3 driver and anything
+// else (libsql://, https://) with the Turso libsql driver.
+func openDatabase(dbURL string) (*sql.DB, error) {
+	if strings.HasPrefix(dbURL, "file:") {
+		db, err := sql.Open("sqlite3", dbURL)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite allows a single writer, so serialize access instead of
+		// surfacing "database is locked" errors under concurrent logins
+		db.SetMaxOpenConns(1)
+		return db, nil
+	}
+	return sql.Open("libsql", dbURL)
+}
+
+// requireDatabase answers 503 for routes that need the database when the
+// server is running without one. Leaderboard listings, opting out and account
+// deletion use the SQL queries and transactions directly, so the store alone
+// is not enough.
+func (api *apiConfig) requireDatabase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.Store == nil || api.DB == nil || api.DBConn == nil {
+			http.Error(w, "Database not configured, this endpoint is unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isDevelopment reports whether APP_ENV names a development environment.
+func isDevelopment(appEnv string) bool {
+	switch strings.ToLower(appEnv) {
+	case "dev", "development", "local":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+// A fireteam has at most six players
+const maxFireteamSize = 6
+
+type FireteamCell struct {
+	Player     string  `json:"player"`
+	Covered    bool    `json:"covered"`
+	Points     float64 `json:"points"`
+	BestWeapon string  `json:"bestWeapon,omitempty"`
+	InstanceID string  `json:"instanceId,omitempty"`
+}
+
+type FireteamBucketRow struct {
+	Bucket string         `json:"bucket"`
+	Cells  []FireteamCell `json:"cells"`
+}
+
+type RoleSuggestion struct {
+	Bucket     string  `json:"bucket"`
+	Player     string  `json:"player"`
+	Weapon     string  `json:"weapon"`
+	InstanceID string  `json:"instanceId,omitempty"`
+	Points     float64 `json:"points"`
+}
+
+type FireteamComparison struct {
+	Players     []MemberRating      `json:"players"`
+	Matrix      []FireteamBucketRow `json:"matrix"`
+	Suggestions []RoleSuggestion    `json:"suggestions"`
+	Uncovered   []string            `json:"uncovered"`
+}
+
+// parseFireteamPlayer accepts either a Bungie name ("Name#1234") or a
+// membership given as "membershipType:membershipId".
+func (api *apiConfig) parseFireteamPlayer(player string) (groupMember, error) {
+	player = strings.TrimSpace(player)
+	if strings.Contains(player, "#") {
+		membership, err := api.resolveBungieName(player)
+		if err != nil {
+			return groupMember{}, fmt.Errorf("%s: %w", player, err)
+		}
+		return groupMember{
+			MembershipID:   membership.MembershipID,
+			MembershipType: membership.MembershipType,
+			DisplayName:    player,
+		}, nil
+	}
+
+	rawType, membershipID, found := strings.Cut(player, ":")
+	if !found {
+		return groupMember{}, fmt.Errorf("%q is neither a Bungie name nor membershipType:membershipId", player)
+	}
+	membershipType, err := strconv.Atoi(rawType)
+	if err != nil {
+		return groupMember{}, fmt.Errorf("invalid membership type in %q", player)
+	}
+	if _, err := strconv.ParseInt(membershipID, 10, 64); err != nil {
+		return groupMember{}, fmt.Errorf("invalid membership ID in %q", player)
+	}
+	return groupMember{MembershipID: membershipID, MembershipType: membershipType, DisplayName: player}, nil
+}
+
+// bestBucketWeapon returns the highest scoring obtained weapon of a bucket.
+func bestBucketWeapon(rating *ResponseData, bucketName string) (WeaponDetail, bool) {
+	var best WeaponDetail
+	found := false
+	for _, detail := range rating.WeaponDetails {
+		if !detail.Obtained || detail.WeaponBucket != bucketName {
+			continue
+		}
+		if !found || detail.Points > best.Points {
+			best = detail
+			found = true
+		}
+	}
+	return best, found
+}
+
+// compareFireteam builds the bucket coverage matrix for rated players and
+// suggests who should bring which role weapon. Buckets are assigned from the
+// most valuable down, each to the player with the best weapon for it; ties go
+// to the player with fewer roles so far so the load is spread.
+func compareFireteam(ratings []MemberRating) FireteamComparison {
+	comparison := FireteamComparison{
+		Players:     ratings,
+		Matrix:      []FireteamBucketRow{},
+		Suggestions: []RoleSuggestion{},
+		Uncovered:   []string{},
+	}
+
+	for _, bp := range constants.BucketPoints {
+		row := FireteamBucketRow{Bucket: bp.BucketName, Cells: []FireteamCell{}}
+		for _, player := range ratings {
+			cell := FireteamCell{Player: player.DisplayName}
+			if player.rating != nil {
+				if weapon, owned := bestBucketWeapon(player.rating, bp.BucketName); owned {
+					cell.Covered = true
+					cell.Points = weapon.Points
+					cell.BestWeapon = weapon.WeaponName
+					if weapon.BestInstance != nil {
+						cell.InstanceID = weapon.BestInstance.InstanceID
+					}
+				}
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		comparison.Matrix = append(comparison.Matrix, row)
+	}
+
+	bucketOrder := append([]constants.BucketPoint(nil), constants.BucketPoints...)
+	sort.SliceStable(bucketOrder, func(i, j int) bool {
+		return bucketOrder[i].MaxPoints > bucketOrder[j].MaxPoints
+	})
+	rows := make(map[string]FireteamBucketRow)
+	for _, row := range comparison.Matrix {
+		rows[row.Bucket] = row
+	}
+
+	// Roles per player, indexed like ratings since display names may repeat
+	assignments := make([]int, len(ratings))
+	for _, bp := range bucketOrder {
+		var chosen *FireteamCell
+		chosenPlayer := -1
+		for i := range rows[bp.BucketName].Cells {
+			cell := &rows[bp.BucketName].Cells[i]
+			if !cell.Covered {
+				continue
+			}
+			if chosen == nil ||
+				cell.Points > chosen.Points ||
+				(cell.Points == chosen.Points && assignments[i] < assignments[chosenPlayer]) {
+				chosen = cell
+				chosenPlayer = i
+			}
+		}
+		if chosen == nil {
+			comparison.Uncovered = append(comparison.Uncovered, bp.BucketName)
+			continue
+		}
+		assignments[chosenPlayer]++
+		comparison.Suggestions = append(comparison.Suggestions, RoleSuggestion{
+			Bucket:     bp.BucketName,
+			Player:     chosen.Player,
+			Weapon:     chosen.BestWeapon,
+			InstanceID: chosen.InstanceID,
+			Points:     chosen.Points,
+		})
+	}
+
+	return comparison
+}
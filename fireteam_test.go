@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+// fireteamPlayer builds a rated player owning one weapon per given bucket,
+// worth the given points. The weapon's instance ID is player/bucket.
+func fireteamPlayer(name string, points map[string]float64) MemberRating {
+	rating := &ResponseData{}
+	for bucket, value := range points {
+		rating.WeaponDetails = append(rating.WeaponDetails, WeaponDetail{
+			WeaponName:   bucket + " weapon",
+			WeaponBucket: bucket,
+			Points:       value,
+			Obtained:     true,
+			BestInstance: &InstanceDetail{InstanceID: name + "/" + bucket},
+		})
+	}
+	return MemberRating{DisplayName: name, rating: rating}
+}
+
+func TestCompareFireteam(t *testing.T) {
+	// Both buckets share the highest MaxPoints, so they are assigned first and in order
+	first := constants.BucketPoints[0].BucketName
+	second := constants.BucketPoints[1].BucketName
+
+	tests := []struct {
+		name      string
+		players   []MemberRating
+		want      []string // Instance IDs suggested for the first and second bucket
+		uncovered int
+	}{
+		{
+			name: "best weapon wins each bucket",
+			players: []MemberRating{
+				fireteamPlayer("Alpha", map[string]float64{first: 9}),
+				fireteamPlayer("Bravo", map[string]float64{first: 12, second: 5}),
+			},
+			want:      []string{"Bravo/" + first, "Bravo/" + second},
+			uncovered: len(constants.BucketPoints) - 2,
+		},
+		{
+			name: "ties go to the player with fewer roles",
+			players: []MemberRating{
+				fireteamPlayer("Alpha", map[string]float64{first: 10, second: 10}),
+				fireteamPlayer("Bravo", map[string]float64{second: 10}),
+			},
+			want:      []string{"Alpha/" + first, "Bravo/" + second},
+			uncovered: len(constants.BucketPoints) - 2,
+		},
+		{
+			name: "players sharing a display name are counted apart",
+			players: []MemberRating{
+				fireteamPlayer("Guardian", map[string]float64{first: 10, second: 10}),
+				func() MemberRating {
+					player := fireteamPlayer("Other", map[string]float64{second: 10})
+					player.DisplayName = "Guardian"
+					return player
+				}(),
+			},
+			want:      []string{"Guardian/" + first, "Other/" + second},
+			uncovered: len(constants.BucketPoints) - 2,
+		},
+		{
+			name: "unrated players cover nothing",
+			players: []MemberRating{
+				{DisplayName: "Private", Error: "profile is private"},
+				fireteamPlayer("Alpha", map[string]float64{second: 3}),
+			},
+			want:      []string{"Alpha/" + second},
+			uncovered: len(constants.BucketPoints) - 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := compareFireteam(tt.players)
+			if len(comparison.Matrix) != len(constants.BucketPoints) {
+				t.Fatalf("matrix has %d rows, want %d", len(comparison.Matrix), len(constants.BucketPoints))
+			}
+			for _, row := range comparison.Matrix {
+				if len(row.Cells) != len(tt.players) {
+					t.Fatalf("%s has %d cells, want %d", row.Bucket, len(row.Cells), len(tt.players))
+				}
+			}
+			var got []string
+			for _, suggestion := range comparison.Suggestions {
+				got = append(got, suggestion.InstanceID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggestions = %v, want %v", got, tt.want)
+			}
+			if len(comparison.Uncovered) != tt.uncovered {
+				t.Errorf("%d uncovered buckets, want %d", len(comparison.Uncovered), tt.uncovered)
+			}
+		})
+	}
+}
+
+func TestFireteamRejectsTheSamePlayerTwice(t *testing.T) {
+	api, _, profileFetches := newTestAPI(t)
+	usePublicCaches(t)
+
+	tests := []struct {
+		name    string
+		players []string
+	}{
+		{name: "same membership", players: []string{"3:" + testPrivateID, " 3:" + testPrivateID + " "}},
+		{name: "Bungie name and membership", players: []string{"Guardian#1234", "3:" + testPrivateID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(fireteamRequest{Players: tt.players})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			api.fireteamHandler(rec, httptest.NewRequest(http.MethodPost, "/api/fireteam", bytes.NewReader(body)))
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "same player") {
+				t.Errorf("status = %d, body %q, want a duplicate player error", rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if got := profileFetches.Load(); got != 0 {
+		t.Errorf("profile fetches = %d, want none before the duplicate is rejected", got)
+	}
+}
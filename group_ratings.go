@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+// Maximum number of member profiles fetched from Bungie at the same time
+const groupRatingConcurrency = 4
+
+type MemberRating struct {
+	DisplayName    string  `json:"displayName"`
+	MembershipID   string  `json:"membershipId"`
+	MembershipType int     `json:"membershipType"`
+	TotalPoints    float64 `json:"totalPoints"`
+	Error          string  `json:"error,omitempty"` // Set when the profile is private or failed to load
+
+	rating *ResponseData
+}
+
+type BucketCoverage struct {
+	Name           string   `json:"name"`
+	MaxPoints      float64  `json:"maxPoints"`
+	CoveredBy      []string `json:"coveredBy"`      // Members owning a qualifying weapon in the bucket
+	MissingMembers []string `json:"missingMembers"` // Rated members without one
+	Coverage       float64  `json:"coverage"`       // Share of rated members covering the bucket
+}
+
+type GroupCoverage struct {
+	Members          []MemberRating   `json:"members"`
+	RatedMembers     int              `json:"ratedMembers"`
+	Buckets          []BucketCoverage `json:"buckets"`
+	CoveredCount     int              `json:"coveredCount"`     // Buckets at least one member covers
+	UncoveredBuckets []string         `json:"uncoveredBuckets"` // Buckets nobody covers
+}
+
+// rateGroupMembers rates each member's public profile with bounded concurrency,
+// keeping the members' order.
+func (api *apiConfig) rateGroupMembers(members []groupMember) []MemberRating {
+	ratings := make([]MemberRating, len(members))
+	semaphore := make(chan struct{}, groupRatingConcurrency)
+	var wg sync.WaitGroup
+
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member groupMember) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			memberRating := MemberRating{
+				DisplayName:    member.DisplayName,
+				MembershipID:   member.MembershipID,
+				MembershipType: member.MembershipType,
+			}
+			rating, err := api.ratePublicProfile(member.MembershipType, member.MembershipID)
+			if err != nil {
+				memberRating.Error = err.Error()
+			} else {
+				memberRating.TotalPoints = rating.Rating.InventoryRating.TotalPoints
+				memberRating.rating = &rating.Rating
+			}
+			ratings[i] = memberRating
+		}(i, member)
+	}
+	wg.Wait()
+
+	return ratings
+}
+
+// summarizeGroupCoverage computes which members cover which buckets.
+func summarizeGroupCoverage(ratings []MemberRating) GroupCoverage {
+	coverage := GroupCoverage{
+		Members:          ratings,
+		UncoveredBuckets: []string{},
+	}
+
+	// Keyed by membership ID: display names are not unique within a group
+	covered := make(map[string]map[string]bool) // bucket -> membership ID -> covered
+	for _, member := range ratings {
+		if member.rating == nil {
+			continue
+		}
+		coverage.RatedMembers++
+		for _, bucket := range member.rating.BucketDetails {
+			if covered[bucket.Name] == nil {
+				covered[bucket.Name] = make(map[string]bool)
+			}
+			covered[bucket.Name][member.MembershipID] = bucket.ObtainedCount > 0
+		}
+	}
+
+	for _, bp := range constants.BucketPoints {
+		bucket := BucketCoverage{
+			Name:           bp.BucketName,
+			MaxPoints:      bp.MaxPoints,
+			CoveredBy:      []string{},
+			MissingMembers: []string{},
+		}
+		for _, member := range ratings {
+			if member.rating == nil {
+				continue
+			}
+			if covered[bp.BucketName][member.MembershipID] {
+				bucket.CoveredBy = append(bucket.CoveredBy, member.DisplayName)
+			} else {
+				bucket.MissingMembers = append(bucket.MissingMembers, member.DisplayName)
+			}
+		}
+		if coverage.RatedMembers > 0 {
+			bucket.Coverage = float64(len(bucket.CoveredBy)) / float64(coverage.RatedMembers)
+		}
+		if len(bucket.CoveredBy) > 0 {
+			coverage.CoveredCount++
+		} else {
+			coverage.UncoveredBuckets = append(coverage.UncoveredBuckets, bp.BucketName)
+		}
+		coverage.Buckets = append(coverage.Buckets, bucket)
+	}
+
+	sort.SliceStable(coverage.Members, func(i, j int) bool {
+		return coverage.Members[i].TotalPoints > coverage.Members[j].TotalPoints
+	})
+
+	return coverage
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/go-chi/chi"
+)
+
+// ratedMember builds a rated member that owns a qualifying weapon in each of
+// the given buckets.
+func ratedMember(name, membershipID string, totalPoints float64, ownedBuckets ...string) MemberRating {
+	rating := &ResponseData{}
+	for _, bp := range constants.BucketPoints {
+		bucket := BucketDetail{Name: bp.BucketName}
+		for _, owned := range ownedBuckets {
+			if owned == bp.BucketName {
+				bucket.ObtainedCount = 1
+			}
+		}
+		rating.BucketDetails = append(rating.BucketDetails, bucket)
+	}
+	return MemberRating{DisplayName: name, MembershipID: membershipID, TotalPoints: totalPoints, rating: rating}
+}
+
+func TestSummarizeGroupCoverage(t *testing.T) {
+	first := constants.BucketPoints[0].BucketName
+	second := constants.BucketPoints[1].BucketName
+
+	tests := []struct {
+		name            string
+		members         []MemberRating
+		wantRated       int
+		wantCoveredBy   map[string][]string
+		wantMissing     map[string][]string
+		wantCoverage    map[string]float64
+		wantCovered     int
+		wantMemberOrder []string
+	}{
+		{
+			name: "members split the buckets",
+			members: []MemberRating{
+				ratedMember("Alpha", "1", 10, first),
+				ratedMember("Bravo", "2", 20, first, second),
+			},
+			wantRated:       2,
+			wantCoveredBy:   map[string][]string{first: {"Alpha", "Bravo"}, second: {"Bravo"}},
+			wantMissing:     map[string][]string{first: {}, second: {"Alpha"}},
+			wantCoverage:    map[string]float64{first: 1, second: 0.5},
+			wantCovered:     2,
+			wantMemberOrder: []string{"Bravo", "Alpha"},
+		},
+		{
+			name: "members sharing a display name stay apart",
+			members: []MemberRating{
+				ratedMember("Guardian", "1", 10, first),
+				ratedMember("Guardian", "2", 5),
+			},
+			wantRated:       2,
+			wantCoveredBy:   map[string][]string{first: {"Guardian"}, second: {}},
+			wantMissing:     map[string][]string{first: {"Guardian"}, second: {"Guardian", "Guardian"}},
+			wantCoverage:    map[string]float64{first: 0.5, second: 0},
+			wantCovered:     1,
+			wantMemberOrder: []string{"Guardian", "Guardian"},
+		},
+		{
+			name: "private profiles are left out",
+			members: []MemberRating{
+				{DisplayName: "Private", MembershipID: "1", Error: "profile is private"},
+				ratedMember("Alpha", "2", 10, second),
+			},
+			wantRated:       1,
+			wantCoveredBy:   map[string][]string{first: {}, second: {"Alpha"}},
+			wantMissing:     map[string][]string{first: {"Alpha"}, second: {}},
+			wantCoverage:    map[string]float64{first: 0, second: 1},
+			wantCovered:     1,
+			wantMemberOrder: []string{"Alpha", "Private"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coverage := summarizeGroupCoverage(tt.members)
+			if coverage.RatedMembers != tt.wantRated {
+				t.Errorf("RatedMembers = %d, want %d", coverage.RatedMembers, tt.wantRated)
+			}
+			if coverage.CoveredCount != tt.wantCovered {
+				t.Errorf("CoveredCount = %d, want %d", coverage.CoveredCount, tt.wantCovered)
+			}
+			if len(coverage.UncoveredBuckets) != len(constants.BucketPoints)-tt.wantCovered {
+				t.Errorf("UncoveredBuckets = %v, want %d buckets", coverage.UncoveredBuckets, len(constants.BucketPoints)-tt.wantCovered)
+			}
+			for _, bucket := range coverage.Buckets {
+				want, checked := tt.wantCoveredBy[bucket.Name]
+				if !checked {
+					continue
+				}
+				if !reflect.DeepEqual(bucket.CoveredBy, want) {
+					t.Errorf("%s CoveredBy = %v, want %v", bucket.Name, bucket.CoveredBy, want)
+				}
+				if !reflect.DeepEqual(bucket.MissingMembers, tt.wantMissing[bucket.Name]) {
+					t.Errorf("%s MissingMembers = %v, want %v", bucket.Name, bucket.MissingMembers, tt.wantMissing[bucket.Name])
+				}
+				if bucket.Coverage != tt.wantCoverage[bucket.Name] {
+					t.Errorf("%s Coverage = %v, want %v", bucket.Name, bucket.Coverage, tt.wantCoverage[bucket.Name])
+				}
+			}
+			var order []string
+			for _, member := range coverage.Members {
+				order = append(order, member.DisplayName)
+			}
+			if !reflect.DeepEqual(order, tt.wantMemberOrder) {
+				t.Errorf("member order = %v, want %v", order, tt.wantMemberOrder)
+			}
+		})
+	}
+}
+
+func TestClanCoverageIsCachedPerGroup(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	usePublicCaches(t)
+	router := chi.NewRouter()
+	router.Get("/api/clan/{groupId}/coverage", api.clanCoverageHandler)
+
+	coverage := func() GroupCoverage {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/clan/42/coverage", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+		}
+		var coverage GroupCoverage
+		if err := json.NewDecoder(rec.Body).Decode(&coverage); err != nil {
+			t.Fatal(err)
+		}
+		return coverage
+	}
+	first := coverage()
+	if len(first.Members) != 1 {
+		t.Fatalf("members = %+v, want the one clan member", first.Members)
+	}
+
+	// Bungie going away must not matter while the summary is cached
+	api.BUNGIE_BASE_URL = "http://127.0.0.1:0"
+	if second := coverage(); !reflect.DeepEqual(second, first) {
+		t.Errorf("cached coverage = %+v, want %+v", second, first)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func (api *apiConfig) accountExportHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := api.exportAccount(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to export account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="d2loot-account.json"`)
+	respondWithJSON(w, http.StatusOK, export)
+}
+
+// accountDeletedMessage tells the player that only our copy of the Bungie
+// tokens was removed. Bungie has no endpoint to revoke a refresh token, so
+// access stays listed under the player's Bungie.net authorized applications.
+const accountDeletedMessage = "Account deleted. Your Bungie refresh token was deleted here but not revoked with Bungie; remove D2Loot from the authorized applications in your Bungie.net settings to revoke it."
+
+func (api *apiConfig) accountDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	session, err := store.Get(r, "session-name")
+	if err != nil {
+		http.Error(w, "Failed to get session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	userID, ok := userIDFromSession(w, session)
+	if !ok {
+		return
+	}
+
+	user, err := api.Store.GetUser(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to load user: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := api.deleteAccount(context.Background(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	api.forgetProfile(context.Background(), user)
+
+	// Invalidate and delete the session
+	session.Options.MaxAge = -1
+	session.Values = make(map[interface{}]interface{})
+	if err := session.Save(r, w); err != nil {
+		http.Error(w, "Failed to invalidate session", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": accountDeletedMessage})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+)
+
+func TestAccountDeleteRemovesUserAndWarnsAboutBungieToken(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	useSQLite(t, api)
+	ctx := context.Background()
+	user, err := api.Store.SaveLogin(ctx, datastore.Login{
+		MembershipID:   "1",
+		MembershipType: 3,
+		AccessToken:    "access",
+		RefreshToken:   "refresh",
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	api.accountDeleteHandler(rec, frontendRequest(http.MethodDelete, "/api/account", sessionCookies(t, user.ID)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body["message"], "not revoked with Bungie") {
+		t.Errorf("message = %q, want a note that the token was not revoked", body["message"])
+	}
+	if _, err := api.Store.GetUser(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUser after delete: err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := api.Store.GetAuthTokens(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetAuthTokens after delete: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestAccountDeleteRequiresSession(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	useSQLite(t, api)
+
+	rec := httptest.NewRecorder()
+	api.accountDeleteHandler(rec, frontendRequest(http.MethodDelete, "/api/account", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAccountExportReadsTheStore(t *testing.T) {
+	// The in-memory store has no SQL queries, so the export must not need them
+	api, memory, _ := newTestAPI(t)
+	ctx := context.Background()
+	user, err := memory.SaveLogin(ctx, datastore.Login{MembershipID: "1", MembershipType: 3, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.SaveLeaderboardRating(ctx, datastore.LeaderboardRating{
+		UserID:      user.ID,
+		DisplayName: "Guardian",
+		TotalPoints: 30,
+		MaxPoints:   200,
+		Buckets: []datastore.BucketScore{
+			{Name: "B", CurrentPoints: 20, MaxPoints: 20, Completion: 1},
+			{Name: "A", CurrentPoints: 10, MaxPoints: 20, Completion: 0.5},
+		},
+		RatedAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := memory.CreateUserGoal(ctx, database.CreateUserGoalParams{UserID: user.ID, WeaponName: "Fatebringer", TargetPerks: `["Firefly"]`, MaxGoals: maxUserGoals}); err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := api.exportAccount(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Leaderboard == nil || export.Leaderboard.DisplayName != "Guardian" {
+		t.Fatalf("Leaderboard = %+v, want the opted-in entry", export.Leaderboard)
+	}
+	var buckets []string
+	for _, score := range export.Leaderboard.BucketScores {
+		buckets = append(buckets, score.BucketName)
+	}
+	if strings.Join(buckets, ",") != "A,B" {
+		t.Errorf("bucket scores = %v, want A and B in order", buckets)
+	}
+	if len(export.Goals) != 1 || export.Goals[0].WeaponName != "Fatebringer" {
+		t.Errorf("Goals = %+v, want the Fatebringer goal", export.Goals)
+	}
+	if len(export.Snapshots) != 1 {
+		t.Errorf("got %d snapshots, want 1", len(export.Snapshots))
+	}
+}
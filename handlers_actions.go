@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bungie throttles item actions per account; keep well under its limit.
+const actionMinInterval = 500 * time.Millisecond
+
+// actionLimiter allows one item action per user per interval.
+type actionLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	last      map[int64]time.Time
+	lastPrune time.Time
+}
+
+func newActionLimiter(interval time.Duration) *actionLimiter {
+	return &actionLimiter{
+		interval: interval,
+		last:     make(map[int64]time.Time),
+	}
+}
+
+// Allow records an action for the user and reports whether it may proceed.
+// When it may not, it also returns how long the user should wait.
+func (l *actionLimiter) Allow(userID int64) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// Forget users whose interval has passed, at most once per interval,
+	// so the map only holds recently active users
+	if now.Sub(l.lastPrune) >= l.interval {
+		for id, last := range l.last {
+			if now.Sub(last) >= l.interval {
+				delete(l.last, id)
+			}
+		}
+		l.lastPrune = now
+	}
+	if last, seen := l.last[userID]; seen {
+		if wait := l.interval - now.Sub(last); wait > 0 {
+			return false, wait
+		}
+	}
+	l.last[userID] = now
+	return true, 0
+}
+
+var itemActionLimiter = newActionLimiter(actionMinInterval)
+
+type TransferItemRequest struct {
+	ItemReferenceHash int64  `json:"itemReferenceHash"`
+	ItemID            string `json:"itemId"`
+	CharacterID       string `json:"characterId"`
+	TransferToVault   bool   `json:"transferToVault"`
+	StackSize         int    `json:"stackSize"`
+}
+
+type EquipItemRequest struct {
+	ItemID      string `json:"itemId"`
+	CharacterID string `json:"characterId"`
+}
+
+type ItemActionResponse struct {
+	DryRun   bool        `json:"dryRun"`
+	Endpoint string      `json:"endpoint"`
+	Payload  interface{} `json:"payload"`
+	Message  string      `json:"message"`
+}
+
+// isDryRun reports whether an action should be validated but not sent.
+func (api *apiConfig) isDryRun(r *http.Request) bool {
+	if api.ACTIONS_DRY_RUN {
+		return true
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return dryRun
+}
+
+// runItemAction handles the shared flow of the item action endpoints: CORS,
+// authentication, rate limiting, dry runs and forwarding to Bungie.
+func (api *apiConfig) runItemAction(w http.ResponseWriter, r *http.Request, path string, buildPayload func(membershipType int64) (interface{}, error)) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	user, client, ok := api.authenticatedUser(w, r)
+	if !ok {
+		return
+	}
+
+	payload, err := buildPayload(user.MembershipType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if allowed, wait := itemActionLimiter.Allow(user.ID); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+		http.Error(w, "Too many item actions, slow down", http.StatusTooManyRequests)
+		return
+	}
+
+	response := ItemActionResponse{
+		DryRun:   api.isDryRun(r),
+		Endpoint: path,
+		Payload:  payload,
+	}
+	if response.DryRun {
+		response.Message = "Dry run: request was validated but not sent"
+		respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if err := api.postBungieAction(client, path, payload); err != nil {
+		http.Error(w, "Item action failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	// The cached profile still shows the item where it was
+	api.forgetProfile(context.Background(), user)
+
+	response.Message = "OK"
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (api *apiConfig) transferItemHandler(w http.ResponseWriter, r *http.Request) {
+	api.runItemAction(w, r, "/Platform/Destiny2/Actions/Items/TransferItem/", func(membershipType int64) (interface{}, error) {
+		var params TransferItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		if params.ItemID == "" || params.CharacterID == "" || params.ItemReferenceHash == 0 {
+			return nil, fmt.Errorf("itemReferenceHash, itemId and characterId are required")
+		}
+		if params.StackSize < 1 {
+			params.StackSize = 1
+		}
+		return map[string]interface{}{
+			"itemReferenceHash": params.ItemReferenceHash,
+			"stackSize":         params.StackSize,
+			"transferToVault":   params.TransferToVault,
+			"itemId":            params.ItemID,
+			"characterId":       params.CharacterID,
+			"membershipType":    membershipType,
+		}, nil
+	})
+}
+
+func (api *apiConfig) equipItemHandler(w http.ResponseWriter, r *http.Request) {
+	api.runItemAction(w, r, "/Platform/Destiny2/Actions/Items/EquipItem/", func(membershipType int64) (interface{}, error) {
+		var params EquipItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		if params.ItemID == "" || params.CharacterID == "" {
+			return nil, fmt.Errorf("itemId and characterId are required")
+		}
+		return map[string]interface{}{
+			"itemId":         params.ItemID,
+			"characterId":    params.CharacterID,
+			"membershipType": membershipType,
+		}, nil
+	})
+}
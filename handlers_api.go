@@ -2,13 +2,15 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+	"github.com/gorilla/sessions"
 	"golang.org/x/oauth2"
 )
 
@@ -47,43 +49,17 @@ func (api *apiConfig) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if the user exists in the database
-	user, err := api.DB.GetUserByMembershipID(context.Background(), membershipID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			// User doesn't exist; create a new user
-			user, err = api.DB.CreateUser(context.Background(), database.CreateUserParams{
-				MembershipID:   membershipID,
-				MembershipType: int64(membershipType),
-			})
-			if err != nil {
-				http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-		} else {
-			http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Store tokens in the database
-	err = api.DB.CreateAuthTokens(context.Background(), database.CreateAuthTokensParams{
-		UserID:       user.ID,
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		ExpiresAt:    token.Expiry,
+	// Create or update the user and store their tokens in one transaction
+	user, err := api.Store.SaveLogin(context.Background(), datastore.Login{
+		MembershipID:   membershipID,
+		MembershipType: int64(membershipType),
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+		ExpiresAt:      token.Expiry,
 	})
 	if err != nil {
-		// If tokens already exist, update them
-		if err := api.DB.UpdateAuthTokens(context.Background(), database.UpdateAuthTokensParams{
-			AccessToken:  token.AccessToken,
-			RefreshToken: token.RefreshToken,
-			ExpiresAt:    token.Expiry,
-			UserID:       user.ID,
-		}); err != nil {
-			http.Error(w, "Failed to store tokens: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+		http.Error(w, "Failed to store login: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	// Get the session
@@ -107,31 +83,62 @@ func (api *apiConfig) handleCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "https://www."+api.FRONTEND_DOMAIN+"/dashboard", http.StatusFound)
 }
 
-func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
+// allowFrontendOrigin sets CORS headers for the frontend origin and reports
+// whether the request came from it. It writes the error response otherwise.
+func (api *apiConfig) allowFrontendOrigin(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "https://"+api.FRONTEND_DOMAIN || origin == "https://www."+api.FRONTEND_DOMAIN {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		return true
+	}
+	http.Error(w, "Unauthorized origin", http.StatusUnauthorized)
+	return false
+}
+
+// sessionUserID reads the logged-in user's ID from the session, writing the
+// error response if there is none.
+func sessionUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
 	// Get the session
 	session, err := store.Get(r, "session-name")
 	if err != nil {
 		http.Error(w, "Failed to get session: "+err.Error(), http.StatusInternalServerError)
-		return
+		return 0, false
 	}
+	return userIDFromSession(w, session)
+}
 
+// userIDFromSession reads the user ID from an already loaded session.
+func userIDFromSession(w http.ResponseWriter, session *sessions.Session) (int64, bool) {
 	// Retrieve userID from session
 	userIDInterface, ok := session.Values["userID"]
 	if !ok {
 		http.Error(w, "User not authenticated", http.StatusUnauthorized)
-		return
+		return 0, false
 	}
 	userID, ok := userIDInterface.(int64)
 	if !ok {
 		http.Error(w, "Invalid user ID in session", http.StatusInternalServerError)
-		return
+		return 0, false
+	}
+	return userID, true
+}
+
+// authenticatedUser loads the session user and an HTTP client carrying their
+// Bungie access token, refreshing and storing the token if it has expired.
+func (api *apiConfig) authenticatedUser(w http.ResponseWriter, r *http.Request) (database.User, *http.Client, bool) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return database.User{}, nil, false
 	}
 
 	// Get tokens from the database
-	tokens, err := api.DB.GetAuthTokens(context.Background(), userID)
+	tokens, err := api.Store.GetAuthTokens(context.Background(), userID)
 	if err != nil {
 		http.Error(w, "Failed to get tokens: "+err.Error(), http.StatusInternalServerError)
-		return
+		return database.User{}, nil, false
 	}
 
 	// Check if the access token has expired
@@ -147,11 +154,11 @@ func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
 		newToken, err := tokenSource.Token()
 		if err != nil {
 			http.Error(w, "Failed to refresh token: "+err.Error(), http.StatusInternalServerError)
-			return
+			return database.User{}, nil, false
 		}
 
 		// Update tokens in the database
-		err = api.DB.UpdateAuthTokens(context.Background(), database.UpdateAuthTokensParams{
+		err = api.Store.UpdateAuthTokens(context.Background(), database.UpdateAuthTokensParams{
 			AccessToken:  newToken.AccessToken,
 			RefreshToken: newToken.RefreshToken,
 			ExpiresAt:    newToken.Expiry,
@@ -159,7 +166,7 @@ func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		if err != nil {
 			http.Error(w, "Failed to update tokens: "+err.Error(), http.StatusInternalServerError)
-			return
+			return database.User{}, nil, false
 		}
 
 		oauthToken = newToken
@@ -169,42 +176,77 @@ func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
 	client := oauth2Config.Client(context.Background(), oauthToken)
 
 	// Retrieve user data from the database
-	user, err := api.DB.GetUser(context.Background(), userID)
+	user, err := api.Store.GetUser(context.Background(), userID)
 	if err != nil {
 		http.Error(w, "Failed to get user data: "+err.Error(), http.StatusInternalServerError)
-		return
+		return database.User{}, nil, false
 	}
 
-	// Retrieve the user's profile data
-	profileData, err := api.getPlayerProfile(client, int(user.MembershipType), user.MembershipID)
+	return user, client, true
+}
+
+// authenticatedProfile fetches the session user's Destiny profile.
+func (api *apiConfig) authenticatedProfile(w http.ResponseWriter, r *http.Request) (database.User, *ProfileData, bool) {
+	user, client, ok := api.authenticatedUser(w, r)
+	if !ok {
+		return database.User{}, nil, false
+	}
+
+	// Retrieve the user's profile data, from the cache unless ?refresh=true
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	profileData, err := api.ownProfile(context.Background(), client, user, refresh)
 	if err != nil {
 		http.Error(w, "Failed to get player profile: "+err.Error(), http.StatusInternalServerError)
+		return database.User{}, nil, false
+	}
+
+	return user, profileData, true
+}
+
+func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
+	// Set CORS headers first; rating records leaderboard rows and completes
+	// goals, so nothing may run for a rejected origin
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	user, profileData, ok := api.authenticatedProfile(w, r)
+	if !ok {
 		return
 	}
 
-	// Generate inventory rating
-	responseData, err := api.rateInventory(*profileData)
+	// Generate inventory rating, keeping the per-instance evaluation for goals
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		http.Error(w, "Failed to rate inventory: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	evaluation, err := evaluateInventory(*profileData, weapons)
 	if err != nil {
 		http.Error(w, "Failed to rate inventory: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	responseData := rateEvaluatedInventory(*profileData, weapons, evaluation)
 
-	// Set CORS headers (adjust as needed)
-	origin := r.Header.Get("Origin")
-	if origin == "https://"+api.FRONTEND_DOMAIN || origin == "https://www."+api.FRONTEND_DOMAIN {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	} else {
-		http.Error(w, "Unauthorized origin", http.StatusUnauthorized)
+	api.recordLeaderboardRating(user.ID, &responseData)
+
+	prefs, err := api.loadRatingPreferences(context.Background(), user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load preferences: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusNoContent)
+	responseData.Goals, err = api.goalProgress(context.Background(), user.ID, evaluation)
+	if err != nil {
+		http.Error(w, "Failed to evaluate goals: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	applyRatingPreferences(&responseData, prefs)
 
 	// Write the response as JSON
 	w.WriteHeader(http.StatusOK)
@@ -216,14 +258,7 @@ func (api *apiConfig) userDataHandler(w http.ResponseWriter, r *http.Request) {
 
 func (api *apiConfig) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers first
-	origin := r.Header.Get("Origin")
-	if origin == "https://"+api.FRONTEND_DOMAIN || origin == "https://www."+api.FRONTEND_DOMAIN {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	} else {
-		http.Error(w, "Unauthorized origin", http.StatusUnauthorized)
+	if !api.allowFrontendOrigin(w, r) {
 		return
 	}
 
@@ -247,12 +282,17 @@ func (api *apiConfig) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete the user's tokens in the database
-	err = api.DB.DeleteAuthTokens(context.Background(), userID)
+	err = api.Store.DeleteAuthTokens(context.Background(), userID)
 	if err != nil {
 		http.Error(w, "Failed to delete tokens", http.StatusInternalServerError)
 		return
 	}
 
+	// The cached profile may hold private inventory data
+	if user, err := api.Store.GetUser(context.Background(), userID); err == nil {
+		api.forgetProfile(context.Background(), user)
+	}
+
 	// Invalidate and delete the session
 	session.Options.MaxAge = -1
 	session.Values = make(map[interface{}]interface{})
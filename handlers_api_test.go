@@ -0,0 +1,500 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+	"github.com/gorilla/sessions"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testFrontendDomain = "d2loot.test"
+	testMembershipID   = "4611686018400000001"
+	testPrivateID      = "4611686018400000002"
+	testAccessToken    = "access-token"
+	testRefreshToken   = "refresh-token"
+)
+
+// newFakeBungie serves the OAuth token endpoint, the membership lookup, the
+// Bungie name search, a one member clan, item actions, a profile with an
+// empty inventory and a private profile, counting profile requests.
+func newFakeBungie(t *testing.T, profileFetches *atomic.Int32) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/platform/app/oauth/token/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  testAccessToken,
+			"token_type":    "Bearer",
+			"refresh_token": testRefreshToken,
+			"expires_in":    3600,
+		})
+	})
+	mux.HandleFunc("/Platform/User/GetMembershipsForCurrentUser/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ErrorCode": 1,
+			"Response": map[string]interface{}{
+				"destinyMemberships": []map[string]interface{}{
+					{"membershipId": testMembershipID, "membershipType": 3},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/Platform/Destiny2/3/Profile/"+testMembershipID+"/", func(w http.ResponseWriter, r *http.Request) {
+		profileFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Response": map[string]interface{}{
+				"responseMintedTimestamp": time.Now().UTC().Format(time.RFC3339),
+				"profile": map[string]interface{}{
+					"data": map[string]interface{}{
+						"userInfo": map[string]interface{}{"bungieGlobalDisplayName": "Guardian"},
+					},
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/Platform/Destiny2/3/Profile/"+testPrivateID+"/", func(w http.ResponseWriter, r *http.Request) {
+		profileFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Response": map[string]interface{}{
+				"responseMintedTimestamp": time.Now().UTC().Format(time.RFC3339),
+				"profileInventory":        map[string]interface{}{"privacy": componentPrivacyPrivate},
+			},
+		})
+	})
+	mux.HandleFunc("/Platform/Destiny2/SearchDestinyPlayerByBungieName/-1/", func(w http.ResponseWriter, r *http.Request) {
+		var search struct {
+			DisplayName     string `json:"displayName"`
+			DisplayNameCode int    `json:"displayNameCode"`
+		}
+		json.NewDecoder(r.Body).Decode(&search)
+		memberships := []map[string]interface{}{}
+		if search.DisplayName == "Guardian" && search.DisplayNameCode == 1234 {
+			memberships = append(memberships, map[string]interface{}{"membershipId": testPrivateID, "membershipType": 3})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ErrorCode": 1, "Response": memberships})
+	})
+	mux.HandleFunc("/Platform/GroupV2/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ErrorCode": 1,
+			"Response": map[string]interface{}{
+				"results": []map[string]interface{}{{
+					"destinyUserInfo": map[string]interface{}{
+						"membershipId":                testPrivateID,
+						"membershipType":              3,
+						"bungieGlobalDisplayName":     "Guardian",
+						"bungieGlobalDisplayNameCode": 1234,
+					},
+				}},
+				"hasMore": false,
+			},
+		})
+	})
+	mux.HandleFunc("/Platform/Destiny2/Actions/Items/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ErrorCode": 1, "ErrorStatus": "Success"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestAPI wires the handlers to an in-memory store and a fake Bungie.
+func newTestAPI(t *testing.T) (*apiConfig, *datastore.Memory, *atomic.Int32) {
+	t.Helper()
+	profileFetches := &atomic.Int32{}
+	bungie := newFakeBungie(t, profileFetches)
+
+	previousConfig, previousStore := oauth2Config, store
+	t.Cleanup(func() {
+		oauth2Config, store = previousConfig, previousStore
+	})
+	oauth2Config = &oauth2.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  bungie.URL + "/en/OAuth/Authorize",
+			TokenURL: bungie.URL + "/platform/app/oauth/token/",
+		},
+	}
+	store = sessions.NewCookieStore([]byte("test-session-key"))
+
+	memory := datastore.NewMemory()
+	return &apiConfig{
+		Store:           memory,
+		API_KEY:         "api-key",
+		FRONTEND_DOMAIN: testFrontendDomain,
+		BUNGIE_BASE_URL: bungie.URL,
+		ProfileCache:    newMemoryProfileCache(),
+	}, memory, profileFetches
+}
+
+// login runs the OAuth callback and returns the session cookies it set.
+func login(t *testing.T, api *apiConfig) []*http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	api.handleCallback(rec, httptest.NewRequest(http.MethodGet, "/callback?code=auth-code", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	return rec.Result().Cookies()
+}
+
+func frontendRequest(method, target string, cookies []*http.Cookie) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Origin", "https://www."+testFrontendDomain)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestHandleCallbackCreatesUserAndTokens(t *testing.T) {
+	api, memory, _ := newTestAPI(t)
+
+	rec := httptest.NewRecorder()
+	api.handleCallback(rec, httptest.NewRequest(http.MethodGet, "/callback?code=auth-code", nil))
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	if location := rec.Header().Get("Location"); location != "https://www."+testFrontendDomain+"/dashboard" {
+		t.Errorf("redirect = %q", location)
+	}
+	if len(rec.Result().Cookies()) == 0 {
+		t.Error("no session cookie set")
+	}
+
+	user, err := memory.GetUserByMembershipID(context.Background(), testMembershipID)
+	if err != nil {
+		t.Fatalf("user not created: %v", err)
+	}
+	if user.MembershipType != 3 {
+		t.Errorf("membership type = %d, want 3", user.MembershipType)
+	}
+	tokens, err := memory.GetAuthTokens(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("tokens not stored: %v", err)
+	}
+	if tokens.AccessToken != testAccessToken || tokens.RefreshToken != testRefreshToken {
+		t.Errorf("stored tokens = %q/%q", tokens.AccessToken, tokens.RefreshToken)
+	}
+}
+
+func TestHandleCallbackUpdatesReturningUser(t *testing.T) {
+	api, memory, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	existing, err := memory.CreateUser(ctx, database.CreateUserParams{MembershipID: testMembershipID, MembershipType: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.CreateAuthTokens(ctx, database.CreateAuthTokensParams{
+		UserID:       existing.ID,
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		ExpiresAt:    time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	login(t, api)
+
+	user, err := memory.GetUserByMembershipID(ctx, testMembershipID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != existing.ID {
+		t.Errorf("callback created a second user: id %d, want %d", user.ID, existing.ID)
+	}
+	tokens, err := memory.GetAuthTokens(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken != testAccessToken {
+		t.Errorf("access token = %q, want refreshed %q", tokens.AccessToken, testAccessToken)
+	}
+}
+
+func TestHandleCallbackRequiresCode(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+
+	rec := httptest.NewRecorder()
+	api.handleCallback(rec, httptest.NewRequest(http.MethodGet, "/callback", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUserDataRequiresSession(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+
+	rec := httptest.NewRecorder()
+	api.userDataHandler(rec, frontendRequest(http.MethodGet, "/user-data", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestUserDataReturnsRating(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	cookies := login(t, api)
+
+	rec := httptest.NewRecorder()
+	api.userDataHandler(rec, frontendRequest(http.MethodGet, "/user-data", cookies))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "https://www."+testFrontendDomain {
+		t.Errorf("allowed origin = %q", origin)
+	}
+	var response ResponseData
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Username != "Guardian" {
+		t.Errorf("username = %q, want Guardian", response.Username)
+	}
+	if len(response.BucketDetails) == 0 {
+		t.Error("no bucket details in rating")
+	}
+	if response.InventoryRating.TotalPoints != 0 {
+		t.Errorf("empty inventory scored %v points", response.InventoryRating.TotalPoints)
+	}
+}
+
+func TestLogoutDeletesTokensAndSession(t *testing.T) {
+	api, memory, _ := newTestAPI(t)
+	cookies := login(t, api)
+
+	rec := httptest.NewRecorder()
+	api.logoutHandler(rec, frontendRequest(http.MethodPost, "/api/logout", cookies))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	user, err := memory.GetUserByMembershipID(context.Background(), testMembershipID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := memory.GetAuthTokens(context.Background(), user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("tokens still stored after logout, err = %v", err)
+	}
+	cleared := false
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session-name" && cookie.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("session cookie not cleared")
+	}
+}
+
+func TestLogoutRejectsUnknownOrigin(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	cookies := login(t, api)
+
+	req := frontendRequest(http.MethodPost, "/api/logout", cookies)
+	req.Header.Set("Origin", "https://evil.test")
+	rec := httptest.NewRecorder()
+	api.logoutHandler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if !strings.Contains(rec.Body.String(), "Unauthorized origin") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
+
+func TestUserDataCachesProfile(t *testing.T) {
+	api, _, profileFetches := newTestAPI(t)
+	cookies := login(t, api)
+
+	for i, target := range []string{"/user-data", "/user-data", "/user-data?refresh=true"} {
+		rec := httptest.NewRecorder()
+		api.userDataHandler(rec, frontendRequest(http.MethodGet, target, cookies))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, body %q", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	if got := profileFetches.Load(); got != 2 {
+		t.Errorf("profile fetched %d times, want 2 (cached once, refreshed once)", got)
+	}
+}
+
+func TestProfileCacheExpiryHonorsMintedTimestamp(t *testing.T) {
+	now := time.Now()
+
+	var fresh ProfileData
+	fresh.Response.ResponseMintedTimestamp = now.Add(-20 * time.Second).UTC().Format(time.RFC3339)
+	expiresAt, cacheable := profileCacheExpiry(&fresh, now)
+	if !cacheable || expiresAt.After(now.Add(profileCacheTTL-19*time.Second)) {
+		t.Errorf("fresh profile: expiresAt = %v, cacheable = %v", expiresAt, cacheable)
+	}
+
+	var stale ProfileData
+	stale.Response.ResponseMintedTimestamp = now.Add(-2 * profileCacheTTL).UTC().Format(time.RFC3339)
+	if _, cacheable := profileCacheExpiry(&stale, now); cacheable {
+		t.Error("profile minted before the TTL window was cached")
+	}
+
+	var private ProfileData
+	private.Response.ResponseMintedTimestamp = now.UTC().Format(time.RFC3339)
+	private.Response.ProfileInventory.Privacy = componentPrivacyPrivate
+	if _, cacheable := profileCacheExpiry(&private, now); cacheable {
+		t.Error("profile with a private, empty inventory was cached")
+	}
+}
+
+func TestUserDataRejectsUnknownOriginBeforeRating(t *testing.T) {
+	api, _, profileFetches := newTestAPI(t)
+	cookies := login(t, api)
+
+	req := frontendRequest(http.MethodGet, "/user-data", cookies)
+	req.Header.Set("Origin", "https://evil.test")
+	rec := httptest.NewRecorder()
+	api.userDataHandler(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if got := profileFetches.Load(); got != 0 {
+		t.Errorf("profile fetched %d times for a rejected origin", got)
+	}
+}
+
+func TestItemActionDropsCachedProfile(t *testing.T) {
+	api, _, profileFetches := newTestAPI(t)
+	cookies := login(t, api)
+
+	previousLimiter := itemActionLimiter
+	t.Cleanup(func() { itemActionLimiter = previousLimiter })
+	itemActionLimiter = newActionLimiter(0)
+
+	userData := func() {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.userDataHandler(rec, frontendRequest(http.MethodGet, "/user-data", cookies))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("user-data status = %d, body %q", rec.Code, rec.Body.String())
+		}
+	}
+	equip := func(target string) {
+		t.Helper()
+		req := frontendRequest(http.MethodPost, target, cookies)
+		req.Body = io.NopCloser(strings.NewReader(`{"itemId": "6917529000000000001", "characterId": "2305843009200000001"}`))
+		rec := httptest.NewRecorder()
+		api.equipItemHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("equip status = %d, body %q", rec.Code, rec.Body.String())
+		}
+	}
+
+	userData()
+	equip("/api/items/equip?dryRun=true")
+	userData()
+	if got := profileFetches.Load(); got != 1 {
+		t.Errorf("profile fetched %d times after a dry run, want 1", got)
+	}
+
+	equip("/api/items/equip")
+	userData()
+	if got := profileFetches.Load(); got != 2 {
+		t.Errorf("profile fetched %d times after an equip, want 2", got)
+	}
+}
+
+func TestActionLimiterForgetsIdleUsers(t *testing.T) {
+	limiter := newActionLimiter(10 * time.Millisecond)
+	if ok, _ := limiter.Allow(1); !ok {
+		t.Fatal("first action was refused")
+	}
+	if ok, wait := limiter.Allow(1); ok || wait <= 0 {
+		t.Fatalf("second action within the interval: ok = %v, wait = %v", ok, wait)
+	}
+	limiter.Allow(2)
+
+	time.Sleep(20 * time.Millisecond)
+	if ok, _ := limiter.Allow(3); !ok {
+		t.Fatal("action of a new user was refused")
+	}
+	if len(limiter.last) != 1 {
+		t.Errorf("limiter tracks %d users, want only the active one", len(limiter.last))
+	}
+	if ok, _ := limiter.Allow(1); !ok {
+		t.Error("action after the interval was refused")
+	}
+}
+
+func TestRequireDatabaseNeedsSQLQueries(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	reached := false
+	handler := api.requireDatabase(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/leaderboard", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if reached {
+		t.Error("handler ran with only an in-memory store configured")
+	}
+}
+
+func TestUserDataRecordsOptedInLeaderboardRating(t *testing.T) {
+	api, memory, _ := newTestAPI(t)
+	cookies := login(t, api)
+	ctx := context.Background()
+	user, err := memory.GetUserByMembershipID(ctx, testMembershipID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.SaveLeaderboardRating(ctx, datastore.LeaderboardRating{UserID: user.ID, RatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	api.userDataHandler(rec, frontendRequest(http.MethodGet, "/user-data", cookies))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	entry, err := memory.GetLeaderboardEntry(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.DisplayName != "Guardian" {
+		t.Errorf("leaderboard name = %q, want Guardian", entry.DisplayName)
+	}
+	snapshots, err := memory.ListRatingSnapshots(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Errorf("got %d snapshots within one interval, want 1", len(snapshots))
+	}
+}
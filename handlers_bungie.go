@@ -1,14 +1,26 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 )
 
+var errPlayerNotFound = errors.New("no Destiny player found with that Bungie name")
+
+// bungieURL builds a Bungie URL, honouring a stand-in server when configured.
+func (api *apiConfig) bungieURL(path string) string {
+	if api.BUNGIE_BASE_URL != "" {
+		return api.BUNGIE_BASE_URL + path
+	}
+	return "https://www.bungie.net" + path
+}
+
 func (api *apiConfig) getMembershipData(client *http.Client) (string, int, error) {
-	req, err := http.NewRequest("GET", "https://www.bungie.net/Platform/User/GetMembershipsForCurrentUser/", nil)
+	req, err := http.NewRequest("GET", api.bungieURL("/Platform/User/GetMembershipsForCurrentUser/"), nil)
 	if err != nil {
 		return "", 0, err
 	}
@@ -49,8 +61,127 @@ func (api *apiConfig) getMembershipData(client *http.Client) (string, int, error
 	return result.Response.DestinyMemberships[0].MembershipID, result.Response.DestinyMemberships[0].MembershipType, nil
 }
 
+// searchPlayerByBungieName resolves a Bungie name ("Name#1234") to a Destiny
+// membership, preferring the cross save primary when there is one.
+func (api *apiConfig) searchPlayerByBungieName(client *http.Client, displayName string, displayNameCode int) (string, int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"displayName":     displayName,
+		"displayNameCode": displayNameCode,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequest("POST", api.bungieURL("/Platform/Destiny2/SearchDestinyPlayerByBungieName/-1/"), bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("X-API-Key", api.API_KEY)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Response []struct {
+			MembershipID      string `json:"membershipId"`
+			MembershipType    int    `json:"membershipType"`
+			CrossSaveOverride int    `json:"crossSaveOverride"`
+		} `json:"Response"`
+		ErrorCode   int    `json:"ErrorCode"`
+		ErrorStatus string `json:"ErrorStatus"`
+		Message     string `json:"Message"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if result.ErrorCode != 1 {
+		return "", 0, fmt.Errorf("API error: %s", result.Message)
+	}
+
+	if len(result.Response) == 0 {
+		return "", 0, errPlayerNotFound
+	}
+
+	for _, membership := range result.Response {
+		if membership.CrossSaveOverride == 0 || membership.CrossSaveOverride == membership.MembershipType {
+			return membership.MembershipID, membership.MembershipType, nil
+		}
+	}
+	return result.Response[0].MembershipID, result.Response[0].MembershipType, nil
+}
+
+// groupMember is a clan member's Destiny membership as returned by GroupV2.
+type groupMember struct {
+	MembershipID   string
+	MembershipType int
+	DisplayName    string
+}
+
+// getGroupMembers fetches every member of a Bungie group, following pagination.
+func (api *apiConfig) getGroupMembers(client *http.Client, groupID string) ([]groupMember, error) {
+	members := []groupMember{}
+	for page := 1; ; page++ {
+		url := api.bungieURL(fmt.Sprintf("/Platform/GroupV2/%s/Members/?currentpage=%d", groupID, page))
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-API-Key", api.API_KEY)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Response struct {
+				Results []struct {
+					DestinyUserInfo struct {
+						MembershipID                string `json:"membershipId"`
+						MembershipType              int    `json:"membershipType"`
+						BungieGlobalDisplayName     string `json:"bungieGlobalDisplayName"`
+						BungieGlobalDisplayNameCode int    `json:"bungieGlobalDisplayNameCode"`
+					} `json:"destinyUserInfo"`
+				} `json:"results"`
+				HasMore bool `json:"hasMore"`
+			} `json:"Response"`
+			ErrorCode   int    `json:"ErrorCode"`
+			ErrorStatus string `json:"ErrorStatus"`
+			Message     string `json:"Message"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if result.ErrorCode != 1 {
+			return nil, fmt.Errorf("API error: %s", result.Message)
+		}
+
+		for _, member := range result.Response.Results {
+			info := member.DestinyUserInfo
+			members = append(members, groupMember{
+				MembershipID:   info.MembershipID,
+				MembershipType: info.MembershipType,
+				DisplayName:    fmt.Sprintf("%s#%04d", info.BungieGlobalDisplayName, info.BungieGlobalDisplayNameCode),
+			})
+		}
+
+		if !result.Response.HasMore || len(result.Response.Results) == 0 {
+			return members, nil
+		}
+	}
+}
+
 func (api *apiConfig) getPlayerProfile(client *http.Client, membershipType int, membershipID string) (*ProfileData, error) {
-	url := fmt.Sprintf("https://www.bungie.net/Platform/Destiny2/%d/Profile/%s/?components=100,102,103,200,201,205,300,305", membershipType, membershipID)
+	url := api.bungieURL(fmt.Sprintf("/Platform/Destiny2/%d/Profile/%s/?components=100,102,103,200,201,205,300,304,305,309,310", membershipType, membershipID))
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -82,3 +213,37 @@ func (api *apiConfig) getPlayerProfile(client *http.Client, membershipType int,
 
 	return &profile, nil
 }
+
+// postBungieAction sends a Destiny2 action request and checks Bungie's error code.
+func (api *apiConfig) postBungieAction(client *http.Client, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", api.bungieURL(path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", api.API_KEY)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrorCode   int    `json:"ErrorCode"`
+		ErrorStatus string `json:"ErrorStatus"`
+		Message     string `json:"Message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("unexpected response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.ErrorCode != 1 {
+		return fmt.Errorf("API error: %s (%s)", result.Message, result.ErrorStatus)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+	"github.com/go-chi/chi"
+)
+
+type CreateGoalRequest struct {
+	WeaponName  string   `json:"weaponName"`
+	TargetPerks []string `json:"targetPerks"` // Optional
+}
+
+func (api *apiConfig) listGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Store.ListUserGoals(context.Background(), userID)
+	if err != nil {
+		http.Error(w, "Failed to load goals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	goals := []Goal{}
+	for _, row := range rows {
+		goal, err := toGoal(row)
+		if err != nil {
+			http.Error(w, "Failed to load goals: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		goals = append(goals, goal)
+	}
+
+	respondWithJSON(w, http.StatusOK, goals)
+}
+
+func (api *apiConfig) createGoalHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var params CreateGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.TargetPerks == nil {
+		params.TargetPerks = []string{}
+	}
+
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		http.Error(w, "Failed to load weapon catalog: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := validateGoal(weapons, params.WeaponName, params.TargetPerks); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	targetPerks, err := json.Marshal(params.TargetPerks)
+	if err != nil {
+		http.Error(w, "Failed to encode target perks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	row, err := api.Store.CreateUserGoal(context.Background(), database.CreateUserGoalParams{
+		UserID:      userID,
+		WeaponName:  params.WeaponName,
+		TargetPerks: string(targetPerks),
+		MaxGoals:    maxUserGoals,
+	})
+	if err != nil {
+		if errors.Is(err, datastore.ErrDuplicate) {
+			http.Error(w, "This weapon is already a goal", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, datastore.ErrGoalLimit) {
+			http.Error(w, "Goal limit reached, remove a goal first", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create goal: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	goal, err := toGoal(row)
+	if err != nil {
+		http.Error(w, "Failed to create goal: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, goal)
+}
+
+func (api *apiConfig) deleteGoalHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goalID, err := strconv.ParseInt(chi.URLParam(r, "goalId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := api.Store.DeleteUserGoal(context.Background(), database.DeleteUserGoalParams{
+		ID:     goalID,
+		UserID: userID,
+	})
+	if err != nil {
+		http.Error(w, "Failed to delete goal: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+// Clans are capped at 100 members by Bungie
+const maxClanMembers = 100
+
+// Rating a clan costs a profile request per member, so summaries are cached
+// for as long as public ratings
+var clanCoverageCache = newTTLCache[GroupCoverage](publicRatingTTL)
+
+func (api *apiConfig) clanCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	groupID := chi.URLParam(r, "groupId")
+	if _, err := strconv.ParseInt(groupID, 10, 64); err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	if coverage, found := clanCoverageCache.Get(groupID); found {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		respondWithJSON(w, http.StatusOK, coverage)
+		return
+	}
+
+	members, err := api.getGroupMembers(publicClient, groupID)
+	if err != nil {
+		http.Error(w, "Failed to get clan members: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if len(members) > maxClanMembers {
+		members = members[:maxClanMembers]
+	}
+
+	coverage := summarizeGroupCoverage(api.rateGroupMembers(members))
+	clanCoverageCache.Set(groupID, coverage)
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, coverage)
+}
+
+type fireteamRequest struct {
+	Players []string `json:"players"` // Bungie names or membershipType:membershipId
+}
+
+func (api *apiConfig) fireteamHandler(w http.ResponseWriter, r *http.Request) {
+	var params fireteamRequest
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(params.Players) == 0 || len(params.Players) > maxFireteamSize {
+		http.Error(w, fmt.Sprintf("Provide between 1 and %d players", maxFireteamSize), http.StatusBadRequest)
+		return
+	}
+
+	members := []groupMember{}
+	// A player may be listed once by Bungie name and again by membership, so
+	// duplicates are found after resolving
+	seen := make(map[string]string)
+	for _, player := range params.Players {
+		member, err := api.parseFireteamPlayer(player)
+		if err != nil {
+			if errors.Is(err, errPlayerNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+			} else {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+			return
+		}
+		if first, duplicate := seen[member.MembershipID]; duplicate {
+			http.Error(w, fmt.Sprintf("%q and %q are the same player", first, member.DisplayName), http.StatusBadRequest)
+			return
+		}
+		seen[member.MembershipID] = member.DisplayName
+		members = append(members, member)
+	}
+
+	comparison := compareFireteam(api.rateGroupMembers(members))
+	respondWithJSON(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/adamararcane/d2-loot-backend/internal/migrate"
+)
+
+type HealthResponse struct {
+	Status              string `json:"status"`
+	Database            bool   `json:"database"`
+	SchemaVersion       int64  `json:"schemaVersion"`       // Highest applied migration
+	LatestSchemaVersion int64  `json:"latestSchemaVersion"` // Highest embedded migration
+}
+
+func (api *apiConfig) healthHandler(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "ok"}
+
+	latest, err := latestSchemaVersion()
+	if err != nil {
+		http.Error(w, "Failed to read embedded migrations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.LatestSchemaVersion = latest
+
+	if api.DBConn != nil {
+		response.Database = true
+		response.SchemaVersion, err = migrate.Version(context.Background(), api.DBConn)
+		if err != nil {
+			response.Status = "database unavailable: " + err.Error()
+			respondWithJSON(w, http.StatusServiceUnavailable, response)
+			return
+		}
+		if response.SchemaVersion < latest {
+			response.Status = "migrations pending"
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
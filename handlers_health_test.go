@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthReadsSchemaVersionWithoutWriting(t *testing.T) {
+	db, err := openDatabase("file:" + filepath.Join(t.TempDir(), "d2loot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	api := &apiConfig{DBConn: db}
+
+	rec := httptest.NewRecorder()
+	api.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var response HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.SchemaVersion != 0 || response.Status != "migrations pending" {
+		t.Errorf("unmigrated database reported %+v", response)
+	}
+
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("health check created %d tables", tables)
+	}
+
+	if err := applyMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	api.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "ok" || response.SchemaVersion != response.LatestSchemaVersion {
+		t.Errorf("migrated database reported %+v", response)
+	}
+}
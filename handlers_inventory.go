@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+func (api *apiConfig) vaultCleanupHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	_, profileData, ok := api.authenticatedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		http.Error(w, "Failed to load weapon catalog: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	recommendations, err := recommendVaultCleanup(*profileData, weapons)
+	if err != nil {
+		http.Error(w, "Failed to build cleanup recommendations: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, recommendations)
+}
+
+func (api *apiConfig) loadoutHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	activityName := r.URL.Query().Get("activity")
+	activity, found := constants.FindActivityProfile(activityName)
+	if !found {
+		http.Error(w, "Unknown activity, expected one of "+activityProfileNames(), http.StatusBadRequest)
+		return
+	}
+
+	_, profileData, ok := api.authenticatedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		http.Error(w, "Failed to load weapon catalog: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	loadouts, err := buildLoadouts(*profileData, weapons, activity)
+	if err != nil {
+		http.Error(w, "Failed to build loadouts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, loadouts)
+}
+
+func (api *apiConfig) exportHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		http.Error(w, "Unknown format, expected csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	_, profileData, ok := api.authenticatedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		http.Error(w, "Failed to load weapon catalog: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	evaluation, err := evaluateInventory(*profileData, weapons)
+	if err != nil {
+		http.Error(w, "Failed to rate inventory: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rows := buildExportRows(evaluation, rateEvaluatedInventory(*profileData, weapons, evaluation))
+
+	flush := func() {}
+	if flusher, canFlush := w.(http.Flusher); canFlush {
+		flush = flusher.Flush
+	}
+
+	// Headers are sent with the first row, so later errors can only be logged
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="d2loot-inventory.csv"`)
+		err = writeExportCSV(w, rows, flush)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="d2loot-inventory.ndjson"`)
+		err = writeExportNDJSON(w, rows, flush)
+	}
+	if err != nil {
+		log.Printf("Failed to stream inventory export: %v", err)
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+const (
+	defaultLeaderboardPageSize = 25
+	maxLeaderboardPageSize     = 100
+)
+
+const (
+	leaderboardTotal  = "total"
+	leaderboardBucket = "bucket"
+	leaderboardWeekly = "weekly"
+)
+
+type LeaderboardRow struct {
+	Rank        int64   `json:"rank"`
+	DisplayName string  `json:"displayName"`
+	Value       float64 `json:"value"` // Points, bucket completion (0-1) or weekly gain
+	MaxValue    float64 `json:"maxValue,omitempty"`
+}
+
+type LeaderboardResponse struct {
+	Board        string           `json:"board"`
+	Bucket       string           `json:"bucket,omitempty"`
+	Page         int              `json:"page"`
+	PageSize     int              `json:"pageSize"`
+	TotalEntries int64            `json:"totalEntries"`
+	Entries      []LeaderboardRow `json:"entries"`
+	Me           *LeaderboardRow  `json:"me,omitempty"` // Set when the caller is logged in and opted in
+}
+
+// optionalSessionUserID returns the logged-in user's ID without requiring one.
+func optionalSessionUserID(r *http.Request) (int64, bool) {
+	session, err := store.Get(r, "session-name")
+	if err != nil {
+		return 0, false
+	}
+	userID, ok := session.Values["userID"].(int64)
+	return userID, ok
+}
+
+func parsePageParam(r *http.Request, name string, fallback, max int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, false
+	}
+	if max > 0 && value > max {
+		value = max
+	}
+	return value, true
+}
+
+func bucketExists(name string) bool {
+	for _, bp := range constants.BucketPoints {
+		if bp.BucketName == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (api *apiConfig) leaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	page, ok := parsePageParam(r, "page", 1, 0)
+	if !ok {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	pageSize, ok := parsePageParam(r, "pageSize", defaultLeaderboardPageSize, maxLeaderboardPageSize)
+	if !ok {
+		http.Error(w, "Invalid pageSize", http.StatusBadRequest)
+		return
+	}
+
+	response := LeaderboardResponse{
+		Board:    r.URL.Query().Get("board"),
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  []LeaderboardRow{},
+	}
+	if response.Board == "" {
+		response.Board = leaderboardTotal
+	}
+	offset := int64((page - 1) * pageSize)
+	limit := int64(pageSize)
+	weekStart := time.Now().UTC().Add(-weeklyGainWindow)
+	ctx := context.Background()
+
+	var err error
+	switch response.Board {
+	case leaderboardTotal:
+		err = api.listTotalLeaderboard(ctx, &response, limit, offset)
+	case leaderboardBucket:
+		response.Bucket = r.URL.Query().Get("bucket")
+		if !bucketExists(response.Bucket) {
+			http.Error(w, "Unknown bucket", http.StatusBadRequest)
+			return
+		}
+		err = api.listBucketLeaderboard(ctx, &response, limit, offset)
+	case leaderboardWeekly:
+		err = api.listWeeklyLeaderboard(ctx, &response, weekStart, limit, offset)
+	default:
+		http.Error(w, "Unknown board, expected total, bucket or weekly", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load leaderboard: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if userID, ok := optionalSessionUserID(r); ok {
+		me, err := api.ownLeaderboardRow(ctx, userID, response.Board, response.Bucket, weekStart)
+		if err != nil {
+			http.Error(w, "Failed to load your rank: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Me = me
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (api *apiConfig) listTotalLeaderboard(ctx context.Context, response *LeaderboardResponse, limit, offset int64) error {
+	count, err := api.DB.CountLeaderboardEntries(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := api.DB.ListLeaderboardByTotal(ctx, database.ListLeaderboardByTotalParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return err
+	}
+	response.TotalEntries = count
+	for _, row := range rows {
+		response.Entries = append(response.Entries, LeaderboardRow{
+			Rank:        row.Rank,
+			DisplayName: row.DisplayName,
+			Value:       row.TotalPoints,
+			MaxValue:    row.MaxPoints,
+		})
+	}
+	return nil
+}
+
+func (api *apiConfig) listBucketLeaderboard(ctx context.Context, response *LeaderboardResponse, limit, offset int64) error {
+	count, err := api.DB.CountBucketLeaderboard(ctx, response.Bucket)
+	if err != nil {
+		return err
+	}
+	rows, err := api.DB.ListBucketLeaderboard(ctx, database.ListBucketLeaderboardParams{
+		BucketName: response.Bucket,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return err
+	}
+	response.TotalEntries = count
+	for _, row := range rows {
+		response.Entries = append(response.Entries, LeaderboardRow{
+			Rank:        row.Rank,
+			DisplayName: row.DisplayName,
+			Value:       row.Completion,
+			MaxValue:    1,
+		})
+	}
+	return nil
+}
+
+func (api *apiConfig) listWeeklyLeaderboard(ctx context.Context, response *LeaderboardResponse, weekStart time.Time, limit, offset int64) error {
+	count, err := api.DB.CountLeaderboardEntries(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := api.DB.ListWeeklyGains(ctx, database.ListWeeklyGainsParams{
+		CreatedAt:   weekStart,
+		CreatedAt_2: weekStart,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		return err
+	}
+	response.TotalEntries = count
+	for _, row := range rows {
+		response.Entries = append(response.Entries, LeaderboardRow{
+			Rank:        row.Rank,
+			DisplayName: row.DisplayName,
+			Value:       row.WeeklyGain,
+		})
+	}
+	return nil
+}
+
+// ownLeaderboardRow returns the user's row on the board, or nil if they have
+// not opted in.
+func (api *apiConfig) ownLeaderboardRow(ctx context.Context, userID int64, board, bucket string, weekStart time.Time) (*LeaderboardRow, error) {
+	member, err := api.isLeaderboardMember(ctx, userID)
+	if err != nil || !member {
+		return nil, err
+	}
+	entry, err := api.DB.GetLeaderboardEntry(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	row := &LeaderboardRow{DisplayName: entry.DisplayName}
+
+	switch board {
+	case leaderboardTotal:
+		row.Value = entry.TotalPoints
+		row.MaxValue = entry.MaxPoints
+		row.Rank, err = api.DB.GetTotalPointsRank(ctx, userID)
+	case leaderboardBucket:
+		score, err := api.DB.GetLeaderboardBucketScore(ctx, database.GetLeaderboardBucketScoreParams{
+			UserID:     userID,
+			BucketName: bucket,
+		})
+		if err != nil {
+			return nil, err
+		}
+		row.Value = score.Completion
+		row.MaxValue = 1
+		row.Rank, err = api.DB.GetBucketRank(ctx, database.GetBucketRankParams{UserID: userID, BucketName: bucket})
+		if err != nil {
+			return nil, err
+		}
+	case leaderboardWeekly:
+		row.Value, err = api.weeklyGain(ctx, userID, entry.TotalPoints, weekStart)
+		if err != nil {
+			return nil, err
+		}
+		row.Rank, err = api.DB.GetWeeklyGainRank(ctx, database.GetWeeklyGainRankParams{
+			CreatedAt:   weekStart,
+			CreatedAt_2: weekStart,
+			UserID:      userID,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func (api *apiConfig) leaderboardOptInHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	user, profileData, ok := api.authenticatedProfile(w, r)
+	if !ok {
+		return
+	}
+
+	responseData, err := api.rateInventory(*profileData)
+	if err != nil {
+		http.Error(w, "Failed to rate inventory: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := api.saveLeaderboardRating(context.Background(), user.ID, responseData); err != nil {
+		http.Error(w, "Failed to join leaderboards: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Joined leaderboards"})
+}
+
+func (api *apiConfig) leaderboardOptOutHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.removeLeaderboardRating(context.Background(), userID); err != nil {
+		http.Error(w, "Failed to leave leaderboards: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Left leaderboards"})
+}
+
+// recordLeaderboardRating refreshes an opted-in user's stored rating and
+// fills in their weekly change. Failures are logged rather than failing the
+// dashboard.
+func (api *apiConfig) recordLeaderboardRating(userID int64, rating *ResponseData) {
+	ctx := context.Background()
+	member, err := api.isLeaderboardMember(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to check leaderboard membership for user %d: %v", userID, err)
+		return
+	}
+	if !member {
+		return
+	}
+
+	if err := api.saveLeaderboardRating(ctx, userID, *rating); err != nil {
+		log.Printf("Failed to save leaderboard rating for user %d: %v", userID, err)
+		return
+	}
+
+	gain, err := api.weeklyGain(ctx, userID, rating.InventoryRating.TotalPoints, time.Now().UTC().Add(-weeklyGainWindow))
+	if err != nil {
+		log.Printf("Failed to compute weekly gain for user %d: %v", userID, err)
+		return
+	}
+	rating.InventoryRating.WeeklyChange = gain
+}
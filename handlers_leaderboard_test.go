@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+)
+
+// sessionCookies returns cookies for a session logged in as userID.
+func sessionCookies(t *testing.T, userID int64) []*http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "session-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Values["userID"] = userID
+	if err := session.Save(req, rec); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Result().Cookies()
+}
+
+func TestLeaderboardRanksTiesTheSameOnEveryPage(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	useSQLite(t, api)
+
+	bucket := constants.BucketPoints[0].BucketName
+	ratings := []struct {
+		total, bucketPoints, completion float64
+	}{
+		{total: 100, bucketPoints: 10, completion: 1},
+		{total: 80, bucketPoints: 6, completion: 0.5},
+		{total: 80, bucketPoints: 6, completion: 0.5},
+		{total: 50, bucketPoints: 4, completion: 0.5},
+	}
+	ctx := context.Background()
+	var userIDs []int64
+	for i, rating := range ratings {
+		user, err := api.Store.SaveLogin(ctx, datastore.Login{MembershipID: fmt.Sprint(i + 1), MembershipType: 3, ExpiresAt: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		userIDs = append(userIDs, user.ID)
+		if err := api.Store.SaveLeaderboardRating(ctx, datastore.LeaderboardRating{
+			UserID:      user.ID,
+			DisplayName: fmt.Sprintf("Guardian %d", i+1),
+			TotalPoints: rating.total,
+			MaxPoints:   200,
+			Buckets:     []datastore.BucketScore{{Name: bucket, CurrentPoints: rating.bucketPoints, MaxPoints: 10, Completion: rating.completion}},
+			RatedAt:     time.Now().UTC(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The caller is the second of the tied players, which lands on page two
+	cookies := sessionCookies(t, userIDs[2])
+	tests := []struct {
+		query     string
+		wantRanks []int64
+		wantMe    int64
+	}{
+		{query: "board=total&pageSize=2&page=1", wantRanks: []int64{1, 2}, wantMe: 2},
+		{query: "board=total&pageSize=2&page=2", wantRanks: []int64{2, 4}, wantMe: 2},
+		{query: "board=bucket&bucket=" + url.QueryEscape(bucket) + "&pageSize=2&page=2", wantRanks: []int64{2, 4}, wantMe: 2},
+		{query: "board=weekly", wantRanks: []int64{1, 1, 1, 1}, wantMe: 1},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		api.leaderboardHandler(rec, frontendRequest(http.MethodGet, "/api/leaderboard?"+tt.query, cookies))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %q", tt.query, rec.Code, rec.Body.String())
+		}
+		var response LeaderboardResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		var ranks []int64
+		for _, row := range response.Entries {
+			ranks = append(ranks, row.Rank)
+		}
+		if !reflect.DeepEqual(ranks, tt.wantRanks) {
+			t.Errorf("%s: ranks = %v, want %v", tt.query, ranks, tt.wantRanks)
+		}
+		if response.Me == nil || response.Me.Rank != tt.wantMe {
+			t.Errorf("%s: me = %+v, want rank %d", tt.query, response.Me, tt.wantMe)
+		}
+	}
+}
+
+func TestWeeklyGainUsesTheRatingFromBeforeTheWeek(t *testing.T) {
+	stores := map[string]func(t *testing.T, api *apiConfig){
+		"memory": func(t *testing.T, api *apiConfig) {},
+		"sqlite": useSQLite,
+	}
+
+	for name, useStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			api, _, _ := newTestAPI(t)
+			useStore(t, api)
+			ctx := context.Background()
+			user, err := api.Store.SaveLogin(ctx, datastore.Login{MembershipID: "1", MembershipType: 3, ExpiresAt: time.Now()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Last visit eight days ago, so its snapshot is older than the window
+			if err := api.Store.SaveLeaderboardRating(ctx, datastore.LeaderboardRating{
+				UserID:      user.ID,
+				DisplayName: "Guardian",
+				TotalPoints: 50,
+				MaxPoints:   200,
+				RatedAt:     time.Now().UTC().Add(-8 * 24 * time.Hour),
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			rating := ResponseData{InventoryRating: InventoryRating{TotalPoints: 80, MaxPossiblePoints: 200}}
+			api.recordLeaderboardRating(user.ID, &rating)
+			if rating.InventoryRating.WeeklyChange != 30 {
+				t.Errorf("WeeklyChange = %v, want 30", rating.InventoryRating.WeeklyChange)
+			}
+
+			if api.DB == nil {
+				return
+			}
+			rec := httptest.NewRecorder()
+			api.leaderboardHandler(rec, frontendRequest(http.MethodGet, "/api/leaderboard?board=weekly", sessionCookies(t, user.ID)))
+			var response LeaderboardResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Entries) != 1 || response.Entries[0].Value != 30 {
+				t.Errorf("weekly entries = %+v, want one gain of 30", response.Entries)
+			}
+			if response.Me == nil || response.Me.Value != 30 || response.Me.Rank != 1 {
+				t.Errorf("me = %+v, want rank 1 with a gain of 30", response.Me)
+			}
+		})
+	}
+}
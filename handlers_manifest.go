@@ -0,0 +1,226 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+)
+
+const maxManifestSearchResults = 100
+
+type ManifestItem struct {
+	Hash         int64   `json:"hash"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Icon         string  `json:"icon"`
+	ItemType     int     `json:"itemType"`
+	ItemSubType  int     `json:"itemSubType"`
+	TypeName     string  `json:"typeName"`
+	TierName     string  `json:"tierName"`
+	DamageType   string  `json:"damageType,omitempty"`
+	AmmoType     string  `json:"ammoType,omitempty"`
+	Source       string  `json:"source,omitempty"`
+	Sunset       bool    `json:"sunset"`
+	DefaultPerks []int64 `json:"defaultPerks,omitempty"`
+}
+
+type ManifestPerk struct {
+	Hash        int64  `json:"hash"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Desired     bool   `json:"desired"`
+}
+
+type ManifestPerkColumn struct {
+	SocketIndex        int            `json:"socketIndex"`
+	SocketCategoryHash int64          `json:"socketCategoryHash"`
+	PlugSetHash        int64          `json:"plugSetHash"`
+	Perks              []ManifestPerk `json:"perks"`
+}
+
+type ManifestWeaponPerks struct {
+	Weapon  ManifestItem         `json:"weapon"`
+	Columns []ManifestPerkColumn `json:"columns"`
+}
+
+// bungieIconURL turns a manifest icon path into an absolute URL
+func bungieIconURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return "https://bungie.net" + path
+}
+
+// toManifestItem converts a raw item definition into its API representation
+func toManifestItem(hash int64, item ItemDefinition) ManifestItem {
+	return ManifestItem{
+		Hash:         hash,
+		Name:         item.DisplayProperties.Name,
+		Description:  item.DisplayProperties.Description,
+		Icon:         bungieIconURL(item.DisplayProperties.Icon),
+		ItemType:     item.ItemType,
+		ItemSubType:  item.ItemSubType,
+		TypeName:     item.ItemTypeDisplayName,
+		TierName:     item.Inventory.TierTypeName,
+		DamageType:   GetDamageTypeName(item),
+		AmmoType:     ammoTypeNames[item.EquippingBlock.AmmoType],
+		Source:       GetCollectibleSource(item),
+		Sunset:       IsSunset(item),
+		DefaultPerks: item.DefaultPerks,
+	}
+}
+
+// parseHashParam reads the {hash} URL parameter as a manifest hash
+func parseHashParam(r *http.Request) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, "hash"), 10, 64)
+}
+
+// manifestItemHandler looks up an item definition by hash. Only weapons and
+// plugs are kept in memory (see isWeaponOrPlug), so armor, emblems,
+// consumables and every other item type answer 404.
+func (api *apiConfig) manifestItemHandler(w http.ResponseWriter, r *http.Request) {
+	hash, err := parseHashParam(r)
+	if err != nil {
+		http.Error(w, "Invalid item hash", http.StatusBadRequest)
+		return
+	}
+
+	item, found := items[strconv.FormatInt(hash, 10)]
+	if !found {
+		http.Error(w, "Item not found; only weapons and plugs can be looked up", http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toManifestItem(hash, item))
+}
+
+func (api *apiConfig) manifestWeaponSearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := strings.ToLower(strings.TrimSpace(query.Get("name")))
+	weaponType := strings.ToLower(strings.TrimSpace(query.Get("type")))
+	damageType := strings.ToLower(strings.TrimSpace(query.Get("damageType")))
+	ammoType := strings.ToLower(strings.TrimSpace(query.Get("ammoType")))
+
+	if name == "" && weaponType == "" && damageType == "" && ammoType == "" {
+		http.Error(w, "At least one of name, type, damageType or ammoType is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := maxManifestSearchResults
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	results := []ManifestItem{}
+	for key, item := range items {
+		if item.ItemType != 3 || item.Redacted || item.DisplayProperties.Name == "" {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(item.DisplayProperties.Name), name) {
+			continue
+		}
+		if weaponType != "" && strings.ToLower(item.ItemTypeDisplayName) != weaponType {
+			continue
+		}
+		if damageType != "" && strings.ToLower(GetDamageTypeName(item)) != damageType {
+			continue
+		}
+		if ammoType != "" && strings.ToLower(ammoTypeNames[item.EquippingBlock.AmmoType]) != ammoType {
+			continue
+		}
+		hash, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		results = append(results, toManifestItem(hash, item))
+	}
+
+	// Sort by name so pagination by limit is stable between requests
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name == results[j].Name {
+			return results[i].Hash < results[j].Hash
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
+
+func (api *apiConfig) manifestWeaponPerksHandler(w http.ResponseWriter, r *http.Request) {
+	hash, err := parseHashParam(r)
+	if err != nil {
+		http.Error(w, "Invalid item hash", http.StatusBadRequest)
+		return
+	}
+
+	weapon, found := items[strconv.FormatInt(hash, 10)]
+	if !found {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return
+	}
+	if weapon.ItemType != 3 {
+		http.Error(w, "Item is not a weapon", http.StatusBadRequest)
+		return
+	}
+
+	// Desired perks come from the tier list entry with the same name, if any
+	desired := make(map[int64]struct{})
+	for _, perkHash := range constants.WeaponDesiredPerks[weapon.DisplayProperties.Name] {
+		desired[perkHash] = struct{}{}
+	}
+
+	lookupPlugSet := func(plugSetHash int64) (PlugSetDefinition, bool) {
+		plugSet, exists := perks[strconv.FormatInt(plugSetHash, 10)]
+		return plugSet, exists
+	}
+
+	response := ManifestWeaponPerks{
+		Weapon:  toManifestItem(hash, weapon),
+		Columns: []ManifestPerkColumn{},
+	}
+	for _, column := range generator.PerkColumns(weapon.Sockets.SocketEntries, lookupPlugSet) {
+		perkColumn := ManifestPerkColumn{
+			SocketIndex: column.SocketIndex,
+			PlugSetHash: column.PlugSetHash,
+			Perks:       []ManifestPerk{},
+		}
+		socketType := weapon.Sockets.SocketEntries[column.SocketIndex].SocketTypeHash
+		if definition, exists := socketTypes[strconv.FormatInt(socketType, 10)]; exists {
+			perkColumn.SocketCategoryHash = definition.SocketCategoryHash
+		}
+		for _, plugHash := range column.PlugHashes {
+			plug, exists := items[strconv.FormatInt(plugHash, 10)]
+			if !exists || plug.DisplayProperties.Name == "" {
+				continue
+			}
+			_, isDesired := desired[plugHash]
+			perkColumn.Perks = append(perkColumn.Perks, ManifestPerk{
+				Hash:        plugHash,
+				Name:        plug.DisplayProperties.Name,
+				Description: plug.DisplayProperties.Description,
+				Icon:        bungieIconURL(plug.DisplayProperties.Icon),
+				Desired:     isDesired,
+			})
+		}
+		response.Columns = append(response.Columns, perkColumn)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
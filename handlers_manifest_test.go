@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+// useTestItems loads a small item table through the runtime filter and swaps
+// it in for the manifest items for the duration of the test.
+func useTestItems(t *testing.T, table string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "DestinyInventoryItemDefinition.json")
+	if err := os.WriteFile(path, []byte(table), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadItemManifestContent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := items
+	t.Cleanup(func() { items = previous })
+	items = loaded
+}
+
+func TestManifestItemHandlerServesWeaponsAndPlugsOnly(t *testing.T) {
+	useTestItems(t, `{
+		"1": {"displayProperties": {"name": "Fatebringer"}, "itemType": 3},
+		"2": {"displayProperties": {"name": "Explosive Payload"}, "itemType": 19, "plug": {"plugCategoryIdentifier": "frames"}},
+		"3": {"displayProperties": {"name": "Helm"}, "itemType": 2},
+		"4": {"displayProperties": {"name": "Emblem"}, "itemType": 14}
+	}`)
+	api := &apiConfig{}
+	router := chi.NewRouter()
+	router.Get("/api/manifest/items/{hash}", api.manifestItemHandler)
+
+	tests := []struct {
+		hash string
+		want int
+		name string
+	}{
+		{hash: "1", want: http.StatusOK, name: "Fatebringer"},
+		{hash: "2", want: http.StatusOK, name: "Explosive Payload"},
+		{hash: "3", want: http.StatusNotFound},
+		{hash: "4", want: http.StatusNotFound},
+		{hash: "abc", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/manifest/items/"+tt.hash, nil))
+		if rec.Code != tt.want {
+			t.Errorf("hash %s: status = %d, want %d", tt.hash, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var item ManifestItem
+		if err := json.NewDecoder(rec.Body).Decode(&item); err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != tt.name {
+			t.Errorf("hash %s: name = %q, want %q", tt.hash, item.Name, tt.name)
+		}
+	}
+}
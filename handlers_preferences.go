@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func (api *apiConfig) getPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := api.loadRatingPreferences(context.Background(), userID)
+	if err != nil {
+		http.Error(w, "Failed to load preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (api *apiConfig) updatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.allowFrontendOrigin(w, r) {
+		return
+	}
+
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var prefs RatingPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if prefs.BucketWeights == nil {
+		prefs.BucketWeights = map[string]float64{}
+	}
+	if prefs.IgnoredBuckets == nil {
+		prefs.IgnoredBuckets = []string{}
+	}
+	if prefs.PreferredSources == nil {
+		prefs.PreferredSources = []string{}
+	}
+	if err := prefs.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := api.saveRatingPreferences(context.Background(), userID, prefs); err != nil {
+		http.Error(w, "Failed to save preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
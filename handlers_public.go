@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// Bungie component privacy value for data hidden by the player's settings
+const componentPrivacyPrivate = 2
+
+// Public ratings are cached so shared links do not hammer Bungie
+const publicRatingTTL = 10 * time.Minute
+
+// Unknown players and private profiles are remembered briefly, so a player
+// who fixes their privacy settings is not locked out for long
+const publicFailureTTL = time.Minute
+
+var errProfilePrivate = errors.New("this player's inventory is private")
+
+// publicClient calls Bungie with only the API key, no user token.
+var publicClient = &http.Client{Timeout: 30 * time.Second}
+
+type publicMembership struct {
+	MembershipID   string
+	MembershipType int
+}
+
+type PublicRating struct {
+	MembershipID   string       `json:"membershipId"`
+	MembershipType int          `json:"membershipType"`
+	SharePath      string       `json:"sharePath"`
+	GeneratedAt    time.Time    `json:"generatedAt"`
+	Rating         ResponseData `json:"rating"`
+}
+
+var publicRatingCache = newTTLCache[PublicRating](publicRatingTTL)
+var bungieNameCache = newTTLCache[publicMembership](publicRatingTTL)
+
+// Failure caches only hold errPlayerNotFound and errProfilePrivate
+var publicRatingFailures = newTTLCache[error](publicFailureTTL)
+var bungieNameFailures = newTTLCache[error](publicFailureTTL)
+
+// isCacheableFailure reports whether err is a lookup result rather than an
+// outage, so repeating the request would give the same answer.
+func isCacheableFailure(err error) bool {
+	return errors.Is(err, errPlayerNotFound) || errors.Is(err, errProfilePrivate)
+}
+
+// parseBungieName splits "Name#1234" into its display name and numeric code.
+func parseBungieName(bungieName string) (string, int, error) {
+	i := strings.LastIndex(bungieName, "#")
+	if i <= 0 || i == len(bungieName)-1 {
+		return "", 0, fmt.Errorf("expected a Bungie name like Name#1234")
+	}
+	code, err := strconv.Atoi(bungieName[i+1:])
+	if err != nil || code < 0 {
+		return "", 0, fmt.Errorf("invalid Bungie name code %q", bungieName[i+1:])
+	}
+	return bungieName[:i], code, nil
+}
+
+// resolveBungieName looks up a membership by Bungie name, using the cache first.
+func (api *apiConfig) resolveBungieName(bungieName string) (publicMembership, error) {
+	cacheKey := strings.ToLower(bungieName)
+	if membership, found := bungieNameCache.Get(cacheKey); found {
+		return membership, nil
+	}
+	if err, found := bungieNameFailures.Get(cacheKey); found {
+		return publicMembership{}, err
+	}
+
+	displayName, code, err := parseBungieName(bungieName)
+	if err != nil {
+		return publicMembership{}, err
+	}
+
+	membershipID, membershipType, err := api.searchPlayerByBungieName(publicClient, displayName, code)
+	if err != nil {
+		if isCacheableFailure(err) {
+			bungieNameFailures.Set(cacheKey, err)
+		}
+		return publicMembership{}, err
+	}
+
+	membership := publicMembership{MembershipID: membershipID, MembershipType: membershipType}
+	bungieNameCache.Set(cacheKey, membership)
+	return membership, nil
+}
+
+// ratePublicProfile rates a profile using only the API key, so it only sees
+// what the player's privacy settings expose. Results are cached, and private
+// profiles are remembered for a shorter time.
+func (api *apiConfig) ratePublicProfile(membershipType int, membershipID string) (PublicRating, error) {
+	cacheKey := fmt.Sprintf("%d:%s", membershipType, membershipID)
+	if rating, found := publicRatingCache.Get(cacheKey); found {
+		return rating, nil
+	}
+	if err, found := publicRatingFailures.Get(cacheKey); found {
+		return PublicRating{}, err
+	}
+
+	profileData, err := api.getPlayerProfile(publicClient, membershipType, membershipID)
+	if err != nil {
+		return PublicRating{}, err
+	}
+	if profileData.Response.ProfileInventory.Privacy == componentPrivacyPrivate ||
+		profileData.Response.CharacterInventories.Privacy == componentPrivacyPrivate {
+		publicRatingFailures.Set(cacheKey, errProfilePrivate)
+		return PublicRating{}, errProfilePrivate
+	}
+
+	responseData, err := api.rateInventory(*profileData)
+	if err != nil {
+		return PublicRating{}, err
+	}
+
+	rating := PublicRating{
+		MembershipID:   membershipID,
+		MembershipType: membershipType,
+		SharePath:      fmt.Sprintf("/api/public/rating/%d/%s", membershipType, membershipID),
+		GeneratedAt:    time.Now().UTC(),
+		Rating:         responseData,
+	}
+	publicRatingCache.Set(cacheKey, rating)
+	return rating, nil
+}
+
+// writePublicRatingError maps lookup errors to status codes.
+func writePublicRatingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errPlayerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errProfilePrivate):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "Failed to rate profile: "+err.Error(), http.StatusBadGateway)
+	}
+}
+
+func (api *apiConfig) publicRatingByNameHandler(w http.ResponseWriter, r *http.Request) {
+	bungieName := strings.TrimSpace(r.URL.Query().Get("name"))
+	if bungieName == "" {
+		http.Error(w, "Missing name parameter", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := parseBungieName(bungieName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	membership, err := api.resolveBungieName(bungieName)
+	if err != nil {
+		writePublicRatingError(w, err)
+		return
+	}
+
+	rating, err := api.ratePublicProfile(membership.MembershipType, membership.MembershipID)
+	if err != nil {
+		writePublicRatingError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, rating)
+}
+
+func (api *apiConfig) publicRatingHandler(w http.ResponseWriter, r *http.Request) {
+	membershipType, err := strconv.Atoi(chi.URLParam(r, "membershipType"))
+	if err != nil {
+		http.Error(w, "Invalid membership type", http.StatusBadRequest)
+		return
+	}
+	membershipID := chi.URLParam(r, "membershipId")
+	if _, err := strconv.ParseInt(membershipID, 10, 64); err != nil {
+		http.Error(w, "Invalid membership ID", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := api.ratePublicProfile(membershipType, membershipID)
+	if err != nil {
+		writePublicRatingError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, rating)
+}
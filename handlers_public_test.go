@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// usePublicCaches gives the test empty public lookup caches.
+func usePublicCaches(t *testing.T) {
+	t.Helper()
+	ratings, names, clans := publicRatingCache, bungieNameCache, clanCoverageCache
+	ratingFailures, nameFailures := publicRatingFailures, bungieNameFailures
+	t.Cleanup(func() {
+		publicRatingCache, bungieNameCache, clanCoverageCache = ratings, names, clans
+		publicRatingFailures, bungieNameFailures = ratingFailures, nameFailures
+	})
+	publicRatingCache = newTTLCache[PublicRating](publicRatingTTL)
+	bungieNameCache = newTTLCache[publicMembership](publicRatingTTL)
+	clanCoverageCache = newTTLCache[GroupCoverage](publicRatingTTL)
+	publicRatingFailures = newTTLCache[error](publicFailureTTL)
+	bungieNameFailures = newTTLCache[error](publicFailureTTL)
+}
+
+func TestParseBungieName(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantName string
+		wantCode int
+		wantErr  bool
+	}{
+		{input: "Name#1234", wantName: "Name", wantCode: 1234},
+		{input: "Leading#0042", wantName: "Leading", wantCode: 42},
+		{input: "Hash#Tag#0007", wantName: "Hash#Tag", wantCode: 7},
+		{input: "#1234", wantErr: true},
+		{input: "Name#", wantErr: true},
+		{input: "Name", wantErr: true},
+		{input: "Name#12ab", wantErr: true},
+		{input: "Name#-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			name, code, err := parseBungieName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBungieName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if name != tt.wantName || code != tt.wantCode {
+				t.Errorf("parseBungieName(%q) = %q, %d, want %q, %d", tt.input, name, code, tt.wantName, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPublicRatingCachesPrivateProfiles(t *testing.T) {
+	api, _, profileFetches := newTestAPI(t)
+	usePublicCaches(t)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		api.publicRatingByNameHandler(rec, httptest.NewRequest(http.MethodGet, "/api/public/rating?name=Guardian%231234", nil))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("request %d: status = %d, body %q", i+1, rec.Code, rec.Body.String())
+		}
+	}
+	if got := profileFetches.Load(); got != 1 {
+		t.Errorf("profile fetches = %d, want 1", got)
+	}
+
+	if _, err := api.ratePublicProfile(3, testPrivateID); !errors.Is(err, errProfilePrivate) {
+		t.Errorf("ratePublicProfile() error = %v, want %v", err, errProfilePrivate)
+	}
+	if got := profileFetches.Load(); got != 1 {
+		t.Errorf("profile fetches after a direct lookup = %d, want 1", got)
+	}
+}
+
+func TestResolveBungieNameCachesUnknownPlayers(t *testing.T) {
+	api, _, _ := newTestAPI(t)
+	usePublicCaches(t)
+
+	if _, err := api.resolveBungieName("Nobody#0001"); !errors.Is(err, errPlayerNotFound) {
+		t.Fatalf("resolveBungieName() error = %v, want %v", err, errPlayerNotFound)
+	}
+	if _, found := bungieNameFailures.Get("nobody#0001"); !found {
+		t.Error("the unknown player was not cached")
+	}
+
+	// An outage is not an answer, so it must not be cached
+	api.BUNGIE_BASE_URL = "http://127.0.0.1:0"
+	if _, err := api.resolveBungieName("Other#0002"); err == nil {
+		t.Fatal("resolveBungieName() succeeded without Bungie")
+	}
+	if _, found := bungieNameFailures.Get("other#0002"); found {
+		t.Error("a network failure was cached")
+	}
+}
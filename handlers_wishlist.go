@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+	"github.com/adamararcane/d2-loot-backend/internal/wishlist"
+)
+
+// wishlistHandler serves the tier list as a DIM wishlist users can subscribe to.
+func (api *apiConfig) wishlistHandler(w http.ResponseWriter, r *http.Request) {
+	catalog, err := generator.ReadWeaponPerkInputs(catalogPath)
+	if err != nil {
+		http.Error(w, "Failed to load weapon catalog: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render first so a failure can still change the status code
+	var body bytes.Buffer
+	if err := wishlist.Render(&body, wishlist.FromCatalog(catalog)); err != nil {
+		http.Error(w, "Failed to render wishlist: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	body.WriteTo(w)
+}
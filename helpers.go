@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"log"
+	"net/http"
 	"strings"
 
 	"github.com/adamararcane/d2-loot-backend/cmd/constants"
@@ -25,3 +28,12 @@ func getRecommendedPerkNames(weaponName string) []string {
 	}
 	return recommendedPerks
 }
+
+// respondWithJSON writes payload as a JSON response with the given status code
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
@@ -83,3 +83,29 @@ func (q *Queries) UpdateAuthTokens(ctx context.Context, arg UpdateAuthTokensPara
 	)
 	return err
 }
+
+const upsertAuthTokens = `-- name: UpsertAuthTokens :exec
+INSERT INTO auth_tokens (user_id, access_token, refresh_token, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET access_token = excluded.access_token,
+    refresh_token = excluded.refresh_token,
+    expires_at = excluded.expires_at
+`
+
+type UpsertAuthTokensParams struct {
+	UserID       int64
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+func (q *Queries) UpsertAuthTokens(ctx context.Context, arg UpsertAuthTokensParams) error {
+	_, err := q.db.ExecContext(ctx, upsertAuthTokens,
+		arg.UserID,
+		arg.AccessToken,
+		arg.RefreshToken,
+		arg.ExpiresAt,
+	)
+	return err
+}
@@ -9,6 +9,14 @@ import (
 	"time"
 )
 
+type AuditLog struct {
+	ID        int64
+	UserID    int64
+	Action    string
+	Detail    string
+	CreatedAt time.Time
+}
+
 type AuthToken struct {
 	UserID       int64
 	AccessToken  string
@@ -17,9 +25,55 @@ type AuthToken struct {
 	CreatedAt    sql.NullTime
 }
 
+type LeaderboardBucketScore struct {
+	UserID        int64
+	BucketName    string
+	CurrentPoints float64
+	MaxPoints     float64
+	Completion    float64
+}
+
+type LeaderboardEntry struct {
+	UserID      int64
+	DisplayName string
+	TotalPoints float64
+	MaxPoints   float64
+	UpdatedAt   time.Time
+}
+
+type ProfileCache struct {
+	MembershipKey string
+	ProfileJson   string
+	ExpiresAt     time.Time
+}
+
+type RatingSnapshot struct {
+	ID          int64
+	UserID      int64
+	TotalPoints float64
+	CreatedAt   time.Time
+}
+
 type User struct {
 	ID             int64
 	MembershipID   string
 	MembershipType int64
 	CreatedAt      sql.NullTime
 }
+
+type UserGoal struct {
+	ID          int64
+	UserID      int64
+	WeaponName  string
+	TargetPerks string
+	CompletedAt sql.NullTime
+	CreatedAt   sql.NullTime
+}
+
+type UserPreference struct {
+	UserID           int64
+	BucketWeights    string
+	IgnoredBuckets   string
+	PreferredSources string
+	UpdatedAt        time.Time
+}
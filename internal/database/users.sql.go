@@ -32,6 +32,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
 const getUser = `-- name: GetUser :one
 SELECT id, membership_id, membership_type, created_at
 FROM users
@@ -67,3 +77,28 @@ func (q *Queries) GetUserByMembershipID(ctx context.Context, membershipID string
 	)
 	return i, err
 }
+
+const upsertUser = `-- name: UpsertUser :one
+INSERT INTO users (membership_id, membership_type)
+VALUES (?, ?)
+ON CONFLICT (membership_id) DO UPDATE
+SET membership_type = excluded.membership_type
+RETURNING id, membership_id, membership_type, created_at
+`
+
+type UpsertUserParams struct {
+	MembershipID   string
+	MembershipType int64
+}
+
+func (q *Queries) UpsertUser(ctx context.Context, arg UpsertUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, upsertUser, arg.MembershipID, arg.MembershipType)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.MembershipID,
+		&i.MembershipType,
+		&i.CreatedAt,
+	)
+	return i, err
+}
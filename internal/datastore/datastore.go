@@ -0,0 +1,222 @@
+// Package datastore defines the storage the HTTP handlers depend on, so they
+// can run against the sqlc queries in production and an in-memory store in
+// tests.
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+// ErrDuplicate is returned when a row would break a unique constraint.
+var ErrDuplicate = errors.New("UNIQUE constraint failed")
+
+// ErrGoalLimit is returned by CreateUserGoal when the user already has
+// MaxGoals goals.
+var ErrGoalLimit = errors.New("goal limit reached")
+
+type UserStore interface {
+	GetUser(ctx context.Context, id int64) (database.User, error)
+	GetUserByMembershipID(ctx context.Context, membershipID string) (database.User, error)
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+}
+
+type TokenStore interface {
+	CreateAuthTokens(ctx context.Context, arg database.CreateAuthTokensParams) error
+	GetAuthTokens(ctx context.Context, userID int64) (database.AuthToken, error)
+	UpdateAuthTokens(ctx context.Context, arg database.UpdateAuthTokensParams) error
+	DeleteAuthTokens(ctx context.Context, userID int64) error
+}
+
+type SnapshotStore interface {
+	CreateRatingSnapshot(ctx context.Context, arg database.CreateRatingSnapshotParams) error
+	GetLatestRatingSnapshot(ctx context.Context, userID int64) (database.RatingSnapshot, error)
+	GetLastRatingSnapshotBefore(ctx context.Context, arg database.GetLastRatingSnapshotBeforeParams) (database.RatingSnapshot, error)
+	GetFirstRatingSnapshotSince(ctx context.Context, arg database.GetFirstRatingSnapshotSinceParams) (database.RatingSnapshot, error)
+	ListRatingSnapshots(ctx context.Context, userID int64) ([]database.RatingSnapshot, error)
+	DeleteRatingSnapshots(ctx context.Context, userID int64) error
+}
+
+type PreferenceStore interface {
+	GetUserPreferences(ctx context.Context, userID int64) (database.UserPreference, error)
+	UpsertUserPreferences(ctx context.Context, arg database.UpsertUserPreferencesParams) error
+}
+
+type GoalStore interface {
+	ListUserGoals(ctx context.Context, userID int64) ([]database.UserGoal, error)
+	// CreateUserGoal returns ErrDuplicate when the weapon is already a goal
+	// and ErrGoalLimit when the user already has arg.MaxGoals goals.
+	CreateUserGoal(ctx context.Context, arg database.CreateUserGoalParams) (database.UserGoal, error)
+	MarkUserGoalCompleted(ctx context.Context, arg database.MarkUserGoalCompletedParams) error
+	DeleteUserGoal(ctx context.Context, arg database.DeleteUserGoalParams) (int64, error)
+}
+
+// RatingSnapshotInterval limits rating snapshots to one per interval so
+// frequent dashboard loads don't flood the history.
+const RatingSnapshotInterval = time.Hour
+
+// BucketScore is one bucket's completion in a leaderboard rating.
+type BucketScore struct {
+	Name          string
+	CurrentPoints float64
+	MaxPoints     float64
+	Completion    float64
+}
+
+// LeaderboardRating is an opted-in player's latest rating.
+type LeaderboardRating struct {
+	UserID      int64
+	DisplayName string
+	TotalPoints float64
+	MaxPoints   float64
+	Buckets     []BucketScore
+	RatedAt     time.Time
+}
+
+type LeaderboardStore interface {
+	GetLeaderboardEntry(ctx context.Context, userID int64) (database.LeaderboardEntry, error)
+	ListLeaderboardBucketScores(ctx context.Context, userID int64) ([]database.LeaderboardBucketScore, error)
+	// SaveLeaderboardRating stores the entry and bucket scores and, at most
+	// once per RatingSnapshotInterval, a rating snapshot, atomically. Saving
+	// the entry is what opts the player in.
+	SaveLeaderboardRating(ctx context.Context, rating LeaderboardRating) error
+}
+
+// Login is what the OAuth callback stores for a signed-in player.
+type Login struct {
+	MembershipID   string
+	MembershipType int64
+	AccessToken    string
+	RefreshToken   string
+	ExpiresAt      time.Time
+}
+
+type LoginStore interface {
+	// SaveLogin creates or updates the user and their tokens atomically, so
+	// simultaneous logins of the same player end with one user row.
+	SaveLogin(ctx context.Context, login Login) (database.User, error)
+}
+
+// Store is everything the login, dashboard and logout handlers need,
+// including the goals, preferences and leaderboard rating the dashboard reads
+// and writes. Lookups of missing rows return sql.ErrNoRows, as the sqlc
+// queries do.
+type Store interface {
+	UserStore
+	TokenStore
+	SnapshotStore
+	LoginStore
+	PreferenceStore
+	GoalStore
+	LeaderboardStore
+}
+
+// SQL is the Store backed by the sqlc generated queries.
+type SQL struct {
+	*database.Queries
+	db *sql.DB
+}
+
+var _ Store = (*SQL)(nil)
+
+func NewSQL(db *sql.DB) *SQL {
+	return &SQL{Queries: database.New(db), db: db}
+}
+
+func (s *SQL) SaveLogin(ctx context.Context, login Login) (database.User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	queries := s.Queries.WithTx(tx)
+
+	user, err := queries.UpsertUser(ctx, database.UpsertUserParams{
+		MembershipID:   login.MembershipID,
+		MembershipType: login.MembershipType,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	if err := queries.UpsertAuthTokens(ctx, database.UpsertAuthTokensParams{
+		UserID:       user.ID,
+		AccessToken:  login.AccessToken,
+		RefreshToken: login.RefreshToken,
+		ExpiresAt:    login.ExpiresAt,
+	}); err != nil {
+		return database.User{}, err
+	}
+
+	return user, tx.Commit()
+}
+
+func (s *SQL) CreateUserGoal(ctx context.Context, arg database.CreateUserGoalParams) (database.UserGoal, error) {
+	arg.UserID_2 = arg.UserID
+	goal, err := s.Queries.CreateUserGoal(ctx, arg)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return database.UserGoal{}, ErrGoalLimit
+	case isUniqueViolation(err):
+		return database.UserGoal{}, fmt.Errorf("%w: %v", ErrDuplicate, err)
+	}
+	return goal, err
+}
+
+// isUniqueViolation reports whether err is a unique constraint failure. The
+// SQLite and libSQL drivers use different error types but the same message.
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (s *SQL) SaveLeaderboardRating(ctx context.Context, rating LeaderboardRating) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := s.Queries.WithTx(tx)
+
+	if err := queries.UpsertLeaderboardEntry(ctx, database.UpsertLeaderboardEntryParams{
+		UserID:      rating.UserID,
+		DisplayName: rating.DisplayName,
+		TotalPoints: rating.TotalPoints,
+		MaxPoints:   rating.MaxPoints,
+		UpdatedAt:   rating.RatedAt,
+	}); err != nil {
+		return fmt.Errorf("saving leaderboard entry: %w", err)
+	}
+
+	for _, bucket := range rating.Buckets {
+		if err := queries.UpsertLeaderboardBucketScore(ctx, database.UpsertLeaderboardBucketScoreParams{
+			UserID:        rating.UserID,
+			BucketName:    bucket.Name,
+			CurrentPoints: bucket.CurrentPoints,
+			MaxPoints:     bucket.MaxPoints,
+			Completion:    bucket.Completion,
+		}); err != nil {
+			return fmt.Errorf("saving bucket score for %s: %w", bucket.Name, err)
+		}
+	}
+
+	latest, err := queries.GetLatestRatingSnapshot(ctx, rating.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("loading latest snapshot: %w", err)
+	}
+	if errors.Is(err, sql.ErrNoRows) || rating.RatedAt.Sub(latest.CreatedAt) >= RatingSnapshotInterval {
+		if err := queries.CreateRatingSnapshot(ctx, database.CreateRatingSnapshotParams{
+			UserID:      rating.UserID,
+			TotalPoints: rating.TotalPoints,
+			CreatedAt:   rating.RatedAt,
+		}); err != nil {
+			return fmt.Errorf("saving rating snapshot: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,392 @@
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+// Memory is an in-memory Store for tests and local experiments.
+type Memory struct {
+	mu             sync.Mutex
+	nextUserID     int64
+	nextSnapshotID int64
+	nextGoalID     int64
+	users          map[int64]database.User
+	tokens         map[int64]database.AuthToken
+	snapshots      []database.RatingSnapshot
+	preferences    map[int64]database.UserPreference
+	goals          []database.UserGoal
+	leaderboard    map[int64]database.LeaderboardEntry
+	bucketScores   map[int64]map[string]database.LeaderboardBucketScore
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		users:        make(map[int64]database.User),
+		tokens:       make(map[int64]database.AuthToken),
+		preferences:  make(map[int64]database.UserPreference),
+		leaderboard:  make(map[int64]database.LeaderboardEntry),
+		bucketScores: make(map[int64]map[string]database.LeaderboardBucketScore),
+	}
+}
+
+func (m *Memory) GetUser(ctx context.Context, id int64) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (m *Memory) GetUserByMembershipID(ctx context.Context, membershipID string) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.MembershipID == membershipID {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.MembershipID == arg.MembershipID {
+			return database.User{}, ErrDuplicate
+		}
+	}
+	m.nextUserID++
+	user := database.User{
+		ID:             m.nextUserID,
+		MembershipID:   arg.MembershipID,
+		MembershipType: arg.MembershipType,
+		CreatedAt:      sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+	m.users[user.ID] = user
+	return user, nil
+}
+
+func (m *Memory) SaveLogin(ctx context.Context, login Login) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var user database.User
+	found := false
+	for _, existing := range m.users {
+		if existing.MembershipID == login.MembershipID {
+			user, found = existing, true
+			break
+		}
+	}
+	if !found {
+		m.nextUserID++
+		user = database.User{
+			ID:           m.nextUserID,
+			MembershipID: login.MembershipID,
+			CreatedAt:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		}
+	}
+	user.MembershipType = login.MembershipType
+	m.users[user.ID] = user
+
+	tokens, exists := m.tokens[user.ID]
+	if !exists {
+		tokens = database.AuthToken{
+			UserID:    user.ID,
+			CreatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		}
+	}
+	tokens.AccessToken = login.AccessToken
+	tokens.RefreshToken = login.RefreshToken
+	tokens.ExpiresAt = login.ExpiresAt
+	m.tokens[user.ID] = tokens
+
+	return user, nil
+}
+
+func (m *Memory) CreateAuthTokens(ctx context.Context, arg database.CreateAuthTokensParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.tokens[arg.UserID]; exists {
+		return ErrDuplicate
+	}
+	m.tokens[arg.UserID] = database.AuthToken{
+		UserID:       arg.UserID,
+		AccessToken:  arg.AccessToken,
+		RefreshToken: arg.RefreshToken,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+	return nil
+}
+
+func (m *Memory) GetAuthTokens(ctx context.Context, userID int64) (database.AuthToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokens, ok := m.tokens[userID]
+	if !ok {
+		return database.AuthToken{}, sql.ErrNoRows
+	}
+	return tokens, nil
+}
+
+func (m *Memory) UpdateAuthTokens(ctx context.Context, arg database.UpdateAuthTokensParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokens, ok := m.tokens[arg.UserID]
+	if !ok {
+		return nil // UPDATE of no rows is not an error
+	}
+	tokens.AccessToken = arg.AccessToken
+	tokens.RefreshToken = arg.RefreshToken
+	tokens.ExpiresAt = arg.ExpiresAt
+	m.tokens[arg.UserID] = tokens
+	return nil
+}
+
+func (m *Memory) DeleteAuthTokens(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, userID)
+	return nil
+}
+
+func (m *Memory) CreateRatingSnapshot(ctx context.Context, arg database.CreateRatingSnapshotParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createSnapshot(arg)
+	return nil
+}
+
+// createSnapshot appends a snapshot. Callers hold mu.
+func (m *Memory) createSnapshot(arg database.CreateRatingSnapshotParams) {
+	m.nextSnapshotID++
+	m.snapshots = append(m.snapshots, database.RatingSnapshot{
+		ID:          m.nextSnapshotID,
+		UserID:      arg.UserID,
+		TotalPoints: arg.TotalPoints,
+		CreatedAt:   arg.CreatedAt,
+	})
+}
+
+// userSnapshots returns the user's snapshots oldest first. Callers hold mu.
+func (m *Memory) userSnapshots(userID int64) []database.RatingSnapshot {
+	snapshots := []database.RatingSnapshot{}
+	for _, snapshot := range m.snapshots {
+		if snapshot.UserID == userID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots
+}
+
+func (m *Memory) GetLatestRatingSnapshot(ctx context.Context, userID int64) (database.RatingSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshots := m.userSnapshots(userID)
+	if len(snapshots) == 0 {
+		return database.RatingSnapshot{}, sql.ErrNoRows
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+func (m *Memory) GetLastRatingSnapshotBefore(ctx context.Context, arg database.GetLastRatingSnapshotBeforeParams) (database.RatingSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshots := m.userSnapshots(arg.UserID)
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if !snapshots[i].CreatedAt.After(arg.CreatedAt) {
+			return snapshots[i], nil
+		}
+	}
+	return database.RatingSnapshot{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetFirstRatingSnapshotSince(ctx context.Context, arg database.GetFirstRatingSnapshotSinceParams) (database.RatingSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, snapshot := range m.userSnapshots(arg.UserID) {
+		if !snapshot.CreatedAt.Before(arg.CreatedAt) {
+			return snapshot, nil
+		}
+	}
+	return database.RatingSnapshot{}, sql.ErrNoRows
+}
+
+func (m *Memory) ListRatingSnapshots(ctx context.Context, userID int64) ([]database.RatingSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.userSnapshots(userID), nil
+}
+
+func (m *Memory) DeleteRatingSnapshots(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.snapshots[:0]
+	for _, snapshot := range m.snapshots {
+		if snapshot.UserID != userID {
+			kept = append(kept, snapshot)
+		}
+	}
+	m.snapshots = kept
+	return nil
+}
+
+func (m *Memory) GetUserPreferences(ctx context.Context, userID int64) (database.UserPreference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefs, ok := m.preferences[userID]
+	if !ok {
+		return database.UserPreference{}, sql.ErrNoRows
+	}
+	return prefs, nil
+}
+
+func (m *Memory) UpsertUserPreferences(ctx context.Context, arg database.UpsertUserPreferencesParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.preferences[arg.UserID] = database.UserPreference{
+		UserID:           arg.UserID,
+		BucketWeights:    arg.BucketWeights,
+		IgnoredBuckets:   arg.IgnoredBuckets,
+		PreferredSources: arg.PreferredSources,
+		UpdatedAt:        arg.UpdatedAt,
+	}
+	return nil
+}
+
+func (m *Memory) ListUserGoals(ctx context.Context, userID int64) ([]database.UserGoal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	goals := []database.UserGoal{}
+	for _, goal := range m.goals {
+		if goal.UserID == userID {
+			goals = append(goals, goal)
+		}
+	}
+	return goals, nil
+}
+
+func (m *Memory) CreateUserGoal(ctx context.Context, arg database.CreateUserGoalParams) (database.UserGoal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, goal := range m.goals {
+		if goal.UserID != arg.UserID {
+			continue
+		}
+		if goal.WeaponName == arg.WeaponName {
+			return database.UserGoal{}, ErrDuplicate
+		}
+		count++
+	}
+	if int64(count) >= arg.MaxGoals {
+		return database.UserGoal{}, ErrGoalLimit
+	}
+	m.nextGoalID++
+	goal := database.UserGoal{
+		ID:          m.nextGoalID,
+		UserID:      arg.UserID,
+		WeaponName:  arg.WeaponName,
+		TargetPerks: arg.TargetPerks,
+		CreatedAt:   sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+	m.goals = append(m.goals, goal)
+	return goal, nil
+}
+
+func (m *Memory) MarkUserGoalCompleted(ctx context.Context, arg database.MarkUserGoalCompletedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.goals {
+		if m.goals[i].ID == arg.ID {
+			m.goals[i].CompletedAt = arg.CompletedAt
+		}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteUserGoal(ctx context.Context, arg database.DeleteUserGoalParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, goal := range m.goals {
+		if goal.ID == arg.ID && goal.UserID == arg.UserID {
+			m.goals = append(m.goals[:i], m.goals[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *Memory) GetLeaderboardEntry(ctx context.Context, userID int64) (database.LeaderboardEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.leaderboard[userID]
+	if !ok {
+		return database.LeaderboardEntry{}, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (m *Memory) ListLeaderboardBucketScores(ctx context.Context, userID int64) ([]database.LeaderboardBucketScore, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	scores := []database.LeaderboardBucketScore{}
+	for _, score := range m.bucketScores[userID] {
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].BucketName < scores[j].BucketName })
+	return scores, nil
+}
+
+func (m *Memory) SaveLeaderboardRating(ctx context.Context, rating LeaderboardRating) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.leaderboard[rating.UserID] = database.LeaderboardEntry{
+		UserID:      rating.UserID,
+		DisplayName: rating.DisplayName,
+		TotalPoints: rating.TotalPoints,
+		MaxPoints:   rating.MaxPoints,
+		UpdatedAt:   rating.RatedAt,
+	}
+
+	scores, ok := m.bucketScores[rating.UserID]
+	if !ok {
+		scores = make(map[string]database.LeaderboardBucketScore)
+		m.bucketScores[rating.UserID] = scores
+	}
+	for _, bucket := range rating.Buckets {
+		scores[bucket.Name] = database.LeaderboardBucketScore{
+			UserID:        rating.UserID,
+			BucketName:    bucket.Name,
+			CurrentPoints: bucket.CurrentPoints,
+			MaxPoints:     bucket.MaxPoints,
+			Completion:    bucket.Completion,
+		}
+	}
+
+	snapshots := m.userSnapshots(rating.UserID)
+	if len(snapshots) == 0 || rating.RatedAt.Sub(snapshots[len(snapshots)-1].CreatedAt) >= RatingSnapshotInterval {
+		m.createSnapshot(database.CreateRatingSnapshotParams{
+			UserID:      rating.UserID,
+			TotalPoints: rating.TotalPoints,
+			CreatedAt:   rating.RatedAt,
+		})
+	}
+	return nil
+}
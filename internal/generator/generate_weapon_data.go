@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/internal/manifest"
 )
 
 type WeaponPerkInput struct {
@@ -16,6 +20,12 @@ type WeaponPerkInput struct {
 	Source       string   `json:"source"`
 	Bucket       string   `json:"bucket"`
 	Rank         string   `json:"rank"`
+	// Versions lists which weapon versions count towards the tier list
+	// ("current", "sunset", "dummy"). Defaults to current versions only.
+	Versions []string `json:"versions,omitempty"`
+	// Optional roll preferences used only at rating time
+	PreferredMasterworks []string       `json:"preferredMasterworks,omitempty"`
+	MinStats             map[string]int `json:"minStats,omitempty"`
 }
 
 type ItemDefinition struct {
@@ -24,21 +34,55 @@ type ItemDefinition struct {
 		Icon        string `json:"icon"`
 		Description string `json:"description"`
 	} `json:"displayProperties"`
-	ItemTypeDisplayName string `json:"itemTypeDisplayName"`
-	ItemSubType         int    `json:"itemSubType"`
-	ItemType            int    `json:"itemType"`
-	Hash                int64  `json:"hash"`
-	Redacted            bool   `json:"redacted"`
-	Sockets             struct {
-		SocketEntries []struct {
-			SingleInitialItemHash int64 `json:"singleInitialItemHash"`
-			ReusablePlugSetHash   int64 `json:"reusablePlugSetHash"`
-			RandomizedPlugSetHash int64 `json:"randomizedPlugSetHash"`
-		} `json:"socketEntries"`
+	ItemTypeDisplayName string  `json:"itemTypeDisplayName"`
+	ItemSubType         int     `json:"itemSubType"`
+	ItemType            int     `json:"itemType"`
+	Hash                int64   `json:"hash"`
+	Redacted            bool    `json:"redacted"`
+	Equippable          bool    `json:"equippable"`
+	ItemCategoryHashes  []int64 `json:"itemCategoryHashes"`
+	Quality             *struct {
+		CurrentVersion int `json:"currentVersion"`
+		Versions       []struct {
+			PowerCapHash int64 `json:"powerCapHash"`
+		} `json:"versions"`
+	} `json:"quality"`
+	Sockets struct {
+		SocketEntries []SocketEntry `json:"socketEntries"`
 	} `json:"sockets"`
+	Plug *struct {
+		PlugCategoryIdentifier string `json:"plugCategoryIdentifier"`
+	} `json:"plug"`
+}
+
+type SocketEntry struct {
+	SocketTypeHash        int64 `json:"socketTypeHash"`
+	SingleInitialItemHash int64 `json:"singleInitialItemHash"`
+	ReusablePlugSetHash   int64 `json:"reusablePlugSetHash"`
+	RandomizedPlugSetHash int64 `json:"randomizedPlugSetHash"`
 }
 
+type PowerCapDefinition struct {
+	PowerCap int `json:"powerCap"`
+}
+
+// Weapon version classes a tier list entry can opt into
+const (
+	VersionCurrent = "current"
+	VersionSunset  = "sunset"
+	VersionDummy   = "dummy"
+)
+
+// dummyItemCategoryHash is the "Dummies" item category used for collections-only copies
+const dummyItemCategoryHash = 3109687656
+
+// sunsetPowerCapThreshold matches the runtime check: caps below it are sunset
+const sunsetPowerCapThreshold = 999900
+
 type PlugSetDefinition struct {
+	DisplayProperties struct {
+		Name string `json:"name"`
+	} `json:"displayProperties"`
 	ReusablePlugItems []struct {
 		PlugItemHash int64 `json:"plugItemHash"`
 	} `json:"reusablePlugItems"`
@@ -48,6 +92,7 @@ func GenerateWeaponData() error {
 	// Paths to files
 	itemDefPath := filepath.Join("..", "..", "DestinyInventoryItemDefinition.json")
 	plugSetDefPath := filepath.Join("..", "..", "DestinyPlugSetDefinition.json")
+	powerCapDefPath := filepath.Join("..", "..", "DestinyPowerCapDefinition.json")
 	weaponsPerksFilePath := filepath.Join("..", "..", "cmd", "generate_constants", "weapons_and_perks.json")
 	outputPath := filepath.Join("..", "constants", "weapon_data.go")
 
@@ -63,14 +108,20 @@ func GenerateWeaponData() error {
 		return fmt.Errorf("error reading plug set definitions: %v", err)
 	}
 
+	// Read the power cap definitions JSON file
+	powerCapDefinitions, err := readPowerCapDefinitions(powerCapDefPath)
+	if err != nil {
+		return fmt.Errorf("error reading power cap definitions: %v", err)
+	}
+
 	// Read the weapons and perks input file
-	weaponInputs, err := readWeaponPerkInputs(weaponsPerksFilePath)
+	weaponInputs, err := ReadWeaponPerkInputs(weaponsPerksFilePath)
 	if err != nil {
 		return fmt.Errorf("error reading weapons and perks input: %v", err)
 	}
 
 	// Find item hashes for the weapons (including all versions)
-	weaponHashesMap, weaponDefinitions, err := findWeaponHashes(itemDefinitions, weaponInputs)
+	weaponHashesMap, weaponDefinitions, err := findWeaponHashes(itemDefinitions, powerCapDefinitions, weaponInputs)
 	if err != nil {
 		return fmt.Errorf("error finding weapon hashes: %v", err)
 	}
@@ -98,20 +149,17 @@ func GenerateWeaponData() error {
 
 // Helper functions
 
+// readItemDefinitions streams the item definitions, keeping only weapons,
+// their collections-only dummies and plugs since those are the only items the
+// generator looks up
 func readItemDefinitions(filePath string) (map[int64]ItemDefinition, error) {
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Remove the outer wrapper (Bungie's JSON files sometimes have additional metadata)
-	raw := json.RawMessage{}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, err
-	}
-
 	itemDefs := make(map[int64]ItemDefinition)
-	if err := json.Unmarshal(raw, &itemDefs); err != nil {
+	err := streamManifestFile(filePath, func(hash int64, item ItemDefinition) {
+		if item.ItemType == 3 || isDummyItem(item) || item.Plug != nil {
+			itemDefs[hash] = item
+		}
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -119,25 +167,49 @@ func readItemDefinitions(filePath string) (map[int64]ItemDefinition, error) {
 }
 
 func readPlugSetDefinitions(filePath string) (map[int64]PlugSetDefinition, error) {
-	data, err := ioutil.ReadFile(filePath)
+	plugSetDefs := make(map[int64]PlugSetDefinition)
+	err := streamManifestFile(filePath, func(hash int64, plugSet PlugSetDefinition) {
+		plugSetDefs[hash] = plugSet
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	raw := json.RawMessage{}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, err
+	return plugSetDefs, nil
+}
+
+// streamManifestFile decodes a manifest table entry by entry with int64 keys
+func streamManifestFile[T any](filePath string, fn func(hash int64, def T)) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	plugSetDefs := make(map[int64]PlugSetDefinition)
-	if err := json.Unmarshal(raw, &plugSetDefs); err != nil {
+	return manifest.Stream(file, func(key string, def T) error {
+		hash, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid manifest hash %q: %w", key, err)
+		}
+		fn(hash, def)
+		return nil
+	})
+}
+
+func readPowerCapDefinitions(filePath string) (map[int64]PowerCapDefinition, error) {
+	powerCapDefs := make(map[int64]PowerCapDefinition)
+	err := streamManifestFile(filePath, func(hash int64, powerCap PowerCapDefinition) {
+		powerCapDefs[hash] = powerCap
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return plugSetDefs, nil
+	return powerCapDefs, nil
 }
 
-func readWeaponPerkInputs(filePath string) ([]WeaponPerkInput, error) {
+// ReadWeaponPerkInputs reads the tier list (weapons_and_perks.json).
+func ReadWeaponPerkInputs(filePath string) ([]WeaponPerkInput, error) {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
@@ -151,28 +223,100 @@ func readWeaponPerkInputs(filePath string) ([]WeaponPerkInput, error) {
 	return inputs, nil
 }
 
-func findWeaponHashes(itemDefs map[int64]ItemDefinition, weaponInputs []WeaponPerkInput) (map[string][]int64, map[int64]ItemDefinition, error) {
+// WriteWeaponPerkInputs writes the tier list back in its two-space indented form.
+func WriteWeaponPerkInputs(filePath string, inputs []WeaponPerkInput) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(inputs)
+}
+
+// isDummyItem reports whether an item is in the "Dummies" category. Dummy
+// copies of weapons have itemType 20 rather than 3.
+func isDummyItem(item ItemDefinition) bool {
+	for _, categoryHash := range item.ItemCategoryHashes {
+		if categoryHash == dummyItemCategoryHash {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyWeaponVersion sorts a weapon definition into current, sunset or
+// collections-only dummy so the tier list can decide which copies count.
+func classifyWeaponVersion(item ItemDefinition, powerCapDefs map[int64]PowerCapDefinition) string {
+	if !item.Equippable || isDummyItem(item) {
+		return VersionDummy
+	}
+	if item.Quality != nil && len(item.Quality.Versions) > 0 {
+		current := item.Quality.CurrentVersion
+		if current < 0 || current >= len(item.Quality.Versions) {
+			current = len(item.Quality.Versions) - 1
+		}
+		powerCap, exists := powerCapDefs[item.Quality.Versions[current].PowerCapHash]
+		if exists && powerCap.PowerCap < sunsetPowerCapThreshold {
+			return VersionSunset
+		}
+	}
+	return VersionCurrent
+}
+
+func findWeaponHashes(itemDefs map[int64]ItemDefinition, powerCapDefs map[int64]PowerCapDefinition, weaponInputs []WeaponPerkInput) (map[string][]int64, map[int64]ItemDefinition, error) {
 	weaponHashes := make(map[string][]int64)
-	weaponNameSet := make(map[string]string) // Map normalized name to original name
+	weaponNameSet := make(map[string]string)                // Map normalized name to original name
+	allowedVersions := make(map[string]map[string]struct{}) // Map original name to version classes that count
 	for _, input := range weaponInputs {
 		normalizedWeaponName := strings.ToLower(strings.TrimSpace(input.WeaponName))
 		weaponNameSet[normalizedWeaponName] = input.WeaponName
+
+		versions := input.Versions
+		if len(versions) == 0 {
+			versions = []string{VersionCurrent}
+		}
+		allowed := make(map[string]struct{})
+		for _, version := range versions {
+			switch version {
+			case VersionCurrent, VersionSunset, VersionDummy:
+				allowed[version] = struct{}{}
+			default:
+				return nil, nil, fmt.Errorf("weapon '%s' has unknown version '%s'", input.WeaponName, version)
+			}
+		}
+		allowedVersions[input.WeaponName] = allowed
 	}
 
 	weaponDefinitions := make(map[int64]ItemDefinition)
+	skipped := make(map[string]int)
 
 	for hash, item := range itemDefs {
-		if item.Redacted {
+		if item.Redacted || (item.ItemType != 3 && !isDummyItem(item)) {
 			continue
 		}
 		itemNameLower := strings.ToLower(strings.TrimSpace(item.DisplayProperties.Name))
 		if originalName, exists := weaponNameSet[itemNameLower]; exists {
+			version := classifyWeaponVersion(item, powerCapDefs)
+			if _, counts := allowedVersions[originalName][version]; !counts {
+				skipped[originalName]++
+				continue
+			}
 			// Add the item's hash to the list for this weapon name
 			weaponHashes[originalName] = append(weaponHashes[originalName], hash)
 			weaponDefinitions[hash] = item
 		}
 	}
 
+	for weaponName, count := range skipped {
+		if len(weaponHashes[weaponName]) == 0 {
+			log.Printf("Warning: all %d versions of '%s' were excluded by its version filter", count, weaponName)
+		}
+	}
+
 	return weaponHashes, weaponDefinitions, nil
 }
 
@@ -193,25 +337,16 @@ func findWeaponPossiblePerkHashes(
 		desiredPerkNameSet[normalizedName] = name
 	}
 
+	lookupPlugSet := func(plugSetHash int64) (PlugSetDefinition, bool) {
+		plugSet, exists := plugSetDefs[plugSetHash]
+		return plugSet, exists
+	}
+
 	for weaponHash, weaponDef := range weaponDefs {
 		possiblePerks := make(map[int64]struct{})
-		if weaponDef.Sockets.SocketEntries != nil {
-			for _, socket := range weaponDef.Sockets.SocketEntries {
-				plugSetHash := socket.RandomizedPlugSetHash
-				if plugSetHash == 0 {
-					plugSetHash = socket.ReusablePlugSetHash
-				}
-				if plugSetHash == 0 {
-					continue
-				}
-				plugSet, exists := plugSetDefs[plugSetHash]
-				if !exists {
-					continue
-				}
-				for _, plugItem := range plugSet.ReusablePlugItems {
-					perkHash := plugItem.PlugItemHash
-					possiblePerks[perkHash] = struct{}{}
-				}
+		for _, column := range PerkColumns(weaponDef.Sockets.SocketEntries, lookupPlugSet) {
+			for _, perkHash := range column.PlugHashes {
+				possiblePerks[perkHash] = struct{}{}
 			}
 		}
 		// Convert to slice
@@ -246,6 +381,44 @@ func findWeaponPossiblePerkHashes(
 	return perkHashes, perkHashesReverse, weaponPossiblePerksMap, nil
 }
 
+// PerkColumn holds the plugs that can roll in a single weapon socket.
+type PerkColumn struct {
+	SocketIndex int
+	PlugSetHash int64
+	PlugHashes  []int64
+}
+
+// PerkColumns groups a weapon's possible plugs by socket, in socket order.
+// Sockets without a randomized or reusable plug set are skipped, as are plug
+// sets the lookup does not know about.
+func PerkColumns(socketEntries []SocketEntry, lookupPlugSet func(plugSetHash int64) (PlugSetDefinition, bool)) []PerkColumn {
+	columns := []PerkColumn{}
+	for i, socket := range socketEntries {
+		plugSetHash := socket.RandomizedPlugSetHash
+		if plugSetHash == 0 {
+			plugSetHash = socket.ReusablePlugSetHash
+		}
+		if plugSetHash == 0 {
+			continue
+		}
+		plugSet, exists := lookupPlugSet(plugSetHash)
+		if !exists {
+			continue
+		}
+		column := PerkColumn{SocketIndex: i, PlugSetHash: plugSetHash}
+		seen := make(map[int64]struct{})
+		for _, plugItem := range plugSet.ReusablePlugItems {
+			if _, dup := seen[plugItem.PlugItemHash]; dup {
+				continue
+			}
+			seen[plugItem.PlugItemHash] = struct{}{}
+			column.PlugHashes = append(column.PlugHashes, plugItem.PlugItemHash)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
 func generateWeaponDataFile(
 	weaponInputs []WeaponPerkInput,
 	weaponHashesMap map[string][]int64,
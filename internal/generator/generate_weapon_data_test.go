@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testSunsetCapHash = 100
+
+func mustItem(t *testing.T, raw string) ItemDefinition {
+	t.Helper()
+	var item ItemDefinition
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestClassifyWeaponVersion(t *testing.T) {
+	powerCaps := map[int64]PowerCapDefinition{
+		testSunsetCapHash: {PowerCap: 1060},
+		200:               {PowerCap: 999990},
+	}
+
+	tests := []struct {
+		name string
+		item string
+		want string
+	}{
+		{
+			name: "current without quality",
+			item: `{"itemType": 3, "equippable": true}`,
+			want: VersionCurrent,
+		},
+		{
+			name: "current with an uncapped power cap",
+			item: `{"itemType": 3, "equippable": true, "quality": {"currentVersion": 0, "versions": [{"powerCapHash": 200}]}}`,
+			want: VersionCurrent,
+		},
+		{
+			name: "sunset power cap",
+			item: `{"itemType": 3, "equippable": true, "quality": {"currentVersion": 0, "versions": [{"powerCapHash": 100}]}}`,
+			want: VersionSunset,
+		},
+		{
+			name: "out of range current version uses the last version",
+			item: `{"itemType": 3, "equippable": true, "quality": {"currentVersion": 5, "versions": [{"powerCapHash": 200}, {"powerCapHash": 100}]}}`,
+			want: VersionSunset,
+		},
+		{
+			name: "unknown power cap counts as current",
+			item: `{"itemType": 3, "equippable": true, "quality": {"currentVersion": 0, "versions": [{"powerCapHash": 300}]}}`,
+			want: VersionCurrent,
+		},
+		{
+			name: "dummy category",
+			item: `{"itemType": 20, "equippable": true, "itemCategoryHashes": [3109687656]}`,
+			want: VersionDummy,
+		},
+		{
+			name: "not equippable",
+			item: `{"itemType": 3, "equippable": false}`,
+			want: VersionDummy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyWeaponVersion(mustItem(t, tt.item), powerCaps); got != tt.want {
+				t.Errorf("classifyWeaponVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindWeaponHashesVersionFilter(t *testing.T) {
+	itemDefs := map[int64]ItemDefinition{
+		1: mustItem(t, `{"displayProperties": {"name": "Fatebringer"}, "itemType": 3, "equippable": true}`),
+		2: mustItem(t, `{"displayProperties": {"name": "Fatebringer"}, "itemType": 3, "equippable": true, "quality": {"currentVersion": 0, "versions": [{"powerCapHash": 100}]}}`),
+		3: mustItem(t, `{"displayProperties": {"name": "Fatebringer"}, "itemType": 20, "itemCategoryHashes": [3109687656]}`),
+		4: mustItem(t, `{"displayProperties": {"name": "Fatebringer"}, "itemType": 3, "equippable": true, "redacted": true}`),
+		5: mustItem(t, `{"displayProperties": {"name": "Fatebringer"}, "itemType": 19}`),
+	}
+	powerCaps := map[int64]PowerCapDefinition{testSunsetCapHash: {PowerCap: 1060}}
+
+	tests := []struct {
+		versions []string
+		want     []int64
+	}{
+		{versions: nil, want: []int64{1}},
+		{versions: []string{VersionSunset}, want: []int64{2}},
+		{versions: []string{VersionDummy}, want: []int64{3}},
+		{versions: []string{VersionCurrent, VersionSunset, VersionDummy}, want: []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		inputs := []WeaponPerkInput{{WeaponName: "Fatebringer", Versions: tt.versions}}
+		hashes, definitions, err := findWeaponHashes(itemDefs, powerCaps, inputs)
+		if err != nil {
+			t.Fatalf("versions %v: %v", tt.versions, err)
+		}
+		got := make(map[int64]bool)
+		for _, hash := range hashes["Fatebringer"] {
+			got[hash] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("versions %v: got hashes %v, want %v", tt.versions, hashes["Fatebringer"], tt.want)
+			continue
+		}
+		for _, hash := range tt.want {
+			if !got[hash] {
+				t.Errorf("versions %v: missing hash %d", tt.versions, hash)
+			}
+			if _, ok := definitions[hash]; !ok {
+				t.Errorf("versions %v: no definition for hash %d", tt.versions, hash)
+			}
+		}
+	}
+}
+
+func TestFindWeaponHashesRejectsUnknownVersion(t *testing.T) {
+	inputs := []WeaponPerkInput{{WeaponName: "Fatebringer", Versions: []string{"adept"}}}
+	if _, _, err := findWeaponHashes(nil, nil, inputs); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
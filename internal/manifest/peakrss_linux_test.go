@@ -0,0 +1,15 @@
+//go:build linux
+
+package manifest
+
+import "syscall"
+
+// peakRSSMegabytes returns the process high-water resident set size
+func peakRSSMegabytes() float64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	// Maxrss is reported in kilobytes on Linux
+	return float64(usage.Maxrss) / 1024
+}
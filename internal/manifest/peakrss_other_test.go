@@ -0,0 +1,8 @@
+//go:build !linux
+
+package manifest
+
+// peakRSSMegabytes is only measured on Linux
+func peakRSSMegabytes() float64 {
+	return 0
+}
@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Stream walks a manifest table of the form {"<hash>": {...}, ...} one entry at
+// a time instead of decoding the whole object at once. Each entry is decoded
+// into a fresh T and handed to fn, so only what fn decides to keep stays alive.
+func Stream[T any](r io.Reader, fn func(key string, def T) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to read manifest start: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected manifest object, got %v", token)
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read manifest key: %w", err)
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("expected manifest key, got %v", token)
+		}
+
+		var def T
+		if err := decoder.Decode(&def); err != nil {
+			return fmt.Errorf("failed to parse manifest entry %s: %w", key, err)
+		}
+		if err := fn(key, def); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to read manifest end: %w", err)
+	}
+	return nil
+}
+
+// LoadFile streams a manifest table from disk, keeping only the entries for
+// which keep returns true. A nil keep retains every entry.
+func LoadFile[T any](filePath string, keep func(def *T) bool) (map[string]T, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer file.Close()
+
+	table := make(map[string]T)
+	err = Stream(file, func(key string, def T) error {
+		if keep == nil || keep(&def) {
+			table[key] = def
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type benchItem struct {
+	DisplayProperties struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	} `json:"displayProperties"`
+	ItemType int `json:"itemType"`
+	Plug     *struct {
+		PlugCategoryIdentifier string `json:"plugCategoryIdentifier"`
+	} `json:"plug"`
+	Sockets struct {
+		SocketEntries []struct {
+			RandomizedPlugSetHash int64 `json:"randomizedPlugSetHash"`
+		} `json:"socketEntries"`
+	} `json:"sockets"`
+}
+
+func keepWeaponsAndPlugs(item *benchItem) bool {
+	return item.ItemType == 3 || item.Plug != nil
+}
+
+// writeSyntheticManifest writes a table shaped like DestinyInventoryItemDefinition
+// where roughly one in ten entries is a weapon and one in five is a plug.
+func writeSyntheticManifest(tb testing.TB, entries int) string {
+	tb.Helper()
+
+	path := filepath.Join(tb.TempDir(), "DestinyInventoryItemDefinition.json")
+	file, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	description := strings.Repeat("Lore text that nobody at runtime needs. ", 20)
+	fmt.Fprint(file, "{")
+	for i := 0; i < entries; i++ {
+		if i > 0 {
+			fmt.Fprint(file, ",")
+		}
+		entry := map[string]interface{}{
+			"displayProperties": map[string]string{
+				"name":        fmt.Sprintf("Item %d", i),
+				"description": description,
+				"icon":        "/common/destiny2_content/icons/example.jpg",
+			},
+			"itemType":   0,
+			"hash":       i,
+			"redacted":   false,
+			"flavorText": description,
+		}
+		switch {
+		case i%10 == 0:
+			entry["itemType"] = 3
+			entry["sockets"] = map[string]interface{}{
+				"socketEntries": []map[string]int64{{"randomizedPlugSetHash": int64(i)}},
+			}
+		case i%5 == 1:
+			entry["plug"] = map[string]string{"plugCategoryIdentifier": "frames"}
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		fmt.Fprintf(file, "%q:%s", fmt.Sprint(i), data)
+	}
+	fmt.Fprint(file, "}")
+
+	return path
+}
+
+// reportRetained records how much heap is still alive while the result is held
+func reportRetained(b *testing.B, result interface{}) {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	b.ReportMetric(float64(stats.HeapAlloc)/(1<<20), "retained-MB")
+	b.ReportMetric(peakRSSMegabytes(), "peak-rss-MB")
+	runtime.KeepAlive(result)
+}
+
+func TestLoadFileKeepsOnlyMatchingEntries(t *testing.T) {
+	path := writeSyntheticManifest(t, 100)
+
+	table, err := LoadFile(path, keepWeaponsAndPlugs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(table) != 30 {
+		t.Fatalf("expected 30 weapons and plugs, got %d", len(table))
+	}
+	if table["10"].ItemType != 3 {
+		t.Errorf("expected entry 10 to be a weapon, got item type %d", table["10"].ItemType)
+	}
+	if _, found := table["2"]; found {
+		t.Errorf("expected entry 2 to be filtered out")
+	}
+}
+
+func TestStreamRejectsNonObject(t *testing.T) {
+	err := Stream(strings.NewReader(`[1, 2, 3]`), func(key string, def benchItem) error { return nil })
+	if err == nil {
+		t.Fatal("expected an error for a non-object manifest")
+	}
+}
+
+// The two benchmarks compare the previous whole-table decode with the streaming
+// loader. peak-rss-MB is process wide, so run them one at a time, e.g.
+//
+//	go test ./internal/manifest -run '^$' -bench DecodeWholeTable
+//	go test ./internal/manifest -run '^$' -bench StreamFiltered
+func BenchmarkDecodeWholeTable(b *testing.B) {
+	path := writeSyntheticManifest(b, 50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var table map[string]benchItem
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		table = nil
+		if err := json.NewDecoder(file).Decode(&table); err != nil {
+			b.Fatal(err)
+		}
+		file.Close()
+	}
+
+	b.StopTimer()
+	reportRetained(b, table)
+}
+
+func BenchmarkStreamFiltered(b *testing.B) {
+	path := writeSyntheticManifest(b, 50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var table map[string]benchItem
+	for i := 0; i < b.N; i++ {
+		var err error
+		table, err = LoadFile(path, keepWeaponsAndPlugs)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.StopTimer()
+	reportRetained(b, table)
+}
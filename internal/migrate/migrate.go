@@ -0,0 +1,268 @@
+// Package migrate applies goose-style SQL migrations from an fs.FS. It keeps
+// its bookkeeping in goose's goose_db_version table, so databases migrated
+// with scripts/migrateup.sh and ones migrated at startup stay interchangeable.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const versionTable = "goose_db_version"
+
+// Migration is one numbered schema file.
+type Migration struct {
+	Version       int64
+	Name          string
+	Up            []string // Statements of the -- +goose Up section
+	NoTransaction bool     // -- +goose NO TRANSACTION: run statements outside a transaction
+}
+
+// Load reads every NNN_name.sql file in fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := make(map[int64]string)
+	for _, name := range names {
+		prefix, _, found := strings.Cut(path.Base(name), "_")
+		if !found {
+			return nil, fmt.Errorf("%s: migration files must be named NNN_description.sql", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("%s: invalid version %q", name, prefix)
+		}
+		if previous, dup := seen[version]; dup {
+			return nil, fmt.Errorf("%s: version %d already used by %s", name, version, previous)
+		}
+		seen[version] = name
+
+		contents, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, noTransaction, err := upStatements(string(contents))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, Up: up, NoTransaction: noTransaction})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// upStatements splits the Up section into statements the way goose does:
+// a statement ends at a line ending in ";" unless it is wrapped in
+// StatementBegin/StatementEnd annotations. It also reports whether the file
+// asks for NO TRANSACTION. Annotations goose supports but this package does
+// not, such as ENVSUB, are rejected rather than silently ignored.
+func upStatements(contents string) ([]string, bool, error) {
+	statements := []string{}
+	var current strings.Builder
+	inUp, sawUp, inBlock, noTransaction := false, false, false, false
+
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "-- +goose") {
+			switch strings.TrimSpace(strings.TrimPrefix(trimmed, "-- +goose")) {
+			case "Up":
+				inUp, sawUp = true, true
+			case "Down":
+				inUp = false
+			case "StatementBegin":
+				inBlock = true
+			case "StatementEnd":
+				inBlock = false
+				if inUp {
+					statements = appendStatement(statements, current.String())
+				}
+				current.Reset()
+			case "NO TRANSACTION":
+				noTransaction = true
+			default:
+				return nil, false, fmt.Errorf("unsupported annotation %q", trimmed)
+			}
+			continue
+		}
+		if !inUp || (!inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--"))) {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			statements = appendStatement(statements, current.String())
+			current.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+	if !sawUp {
+		return nil, false, fmt.Errorf("missing -- +goose Up annotation")
+	}
+	if inBlock {
+		return nil, false, fmt.Errorf("unterminated StatementBegin")
+	}
+	// goose accepts a final statement without a trailing semicolon
+	return appendStatement(statements, current.String()), noTransaction, nil
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if strings.TrimSpace(statement) == "" {
+		return statements
+	}
+	return append(statements, statement)
+}
+
+func ensureVersionTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`)
+	return err
+}
+
+// appliedVersions returns the versions whose latest goose row marks them as
+// applied.
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version_id, is_applied FROM `+versionTable+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var applied bool
+		if err := rows.Scan(&version, &applied); err != nil {
+			return nil, err
+		}
+		if _, seen := latest[version]; !seen {
+			latest[version] = applied
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]bool)
+	for version, isApplied := range latest {
+		if isApplied && version > 0 {
+			applied[version] = true
+		}
+	}
+	return applied, nil
+}
+
+// Version returns the highest applied migration, 0 for an empty database.
+// It only reads, so it is safe to call from health checks.
+func Version(ctx context.Context, db *sql.DB) (int64, error) {
+	var table string
+	err := db.QueryRowContext(ctx,
+		`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, versionTable,
+	).Scan(&table)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the versions it applied.
+func Up(ctx context.Context, db *sql.DB, migrations []Migration) ([]int64, error) {
+	if err := ensureVersionTable(ctx, db); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", versionTable, err)
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", versionTable, err)
+	}
+
+	newlyApplied := []int64{}
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+		if err := apply(ctx, db, migration); err != nil {
+			return newlyApplied, fmt.Errorf("applying %s: %w", migration.Name, err)
+		}
+		newlyApplied = append(newlyApplied, migration.Version)
+	}
+	return newlyApplied, nil
+}
+
+func apply(ctx context.Context, db *sql.DB, migration Migration) error {
+	if migration.NoTransaction {
+		return applyWithoutTransaction(ctx, db, migration)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range migration.Up {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (?, ?)`,
+		migration.Version, true,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyWithoutTransaction runs each statement on its own, as goose does for
+// NO TRANSACTION files. A failure leaves earlier statements applied and the
+// version unrecorded.
+func applyWithoutTransaction(ctx context.Context, db *sql.DB, migration Migration) error {
+	for _, statement := range migration.Up {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (?, ?)`,
+		migration.Version, true,
+	)
+	return err
+}
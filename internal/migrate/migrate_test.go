@@ -0,0 +1,223 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestUpStatements(t *testing.T) {
+	tests := []struct {
+		name          string
+		contents      string
+		want          []string
+		noTransaction bool
+		wantErr       string
+	}{
+		{
+			name: "statements end at semicolons and down is skipped",
+			contents: `-- +goose Up
+-- a comment
+CREATE TABLE a (
+    id INTEGER PRIMARY KEY
+);
+
+CREATE INDEX a_id ON a (id);
+
+-- +goose Down
+DROP TABLE a;
+`,
+			want: []string{
+				"CREATE TABLE a (\n    id INTEGER PRIMARY KEY\n);\n",
+				"CREATE INDEX a_id ON a (id);\n",
+			},
+		},
+		{
+			name: "statement blocks keep inner semicolons",
+			contents: `-- +goose Up
+-- +goose StatementBegin
+CREATE TRIGGER t AFTER INSERT ON a BEGIN
+    UPDATE a SET id = id;
+END;
+-- +goose StatementEnd
+`,
+			want: []string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n    UPDATE a SET id = id;\nEND;\n"},
+		},
+		{
+			name:     "final statement without a semicolon",
+			contents: "-- +goose Up\nCREATE TABLE a (id INTEGER)\n",
+			want:     []string{"CREATE TABLE a (id INTEGER)\n"},
+		},
+		{
+			name:          "no transaction",
+			contents:      "-- +goose NO TRANSACTION\n-- +goose Up\nVACUUM;\n",
+			want:          []string{"VACUUM;\n"},
+			noTransaction: true,
+		},
+		{
+			name:     "missing up",
+			contents: "CREATE TABLE a (id INTEGER);\n",
+			wantErr:  "missing -- +goose Up",
+		},
+		{
+			name:     "unterminated block",
+			contents: "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n",
+			wantErr:  "unterminated StatementBegin",
+		},
+		{
+			name:     "unsupported annotation",
+			contents: "-- +goose Up\n-- +goose ENVSUB ON\nSELECT 1;\n",
+			wantErr:  "unsupported annotation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, noTransaction, err := upStatements(tt.contents)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements = %q, want %q", got, tt.want)
+			}
+			if noTransaction != tt.noTransaction {
+				t.Errorf("noTransaction = %v, want %v", noTransaction, tt.noTransaction)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_later.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n")},
+		"002_first.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n")},
+		"README.md":     {Data: []byte("not a migration")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("migrations = %+v, want versions 2 and 10 in order", migrations)
+	}
+
+	invalid := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "no underscore", fsys: fstest.MapFS{"001.sql": {Data: []byte("-- +goose Up\n")}}},
+		{name: "bad version", fsys: fstest.MapFS{"abc_x.sql": {Data: []byte("-- +goose Up\n")}}},
+		{name: "duplicate version", fsys: fstest.MapFS{
+			"001_a.sql": {Data: []byte("-- +goose Up\n")},
+			"1_b.sql":   {Data: []byte("-- +goose Up\n")},
+		}},
+	}
+	for _, tt := range invalid {
+		if _, err := Load(tt.fsys); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count > 0
+}
+
+func TestUpAppliesPendingMigrationsOnce(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	version, err := Version(ctx, db)
+	if err != nil || version != 0 {
+		t.Fatalf("Version on an empty database = %d, %v", version, err)
+	}
+	if tableExists(t, db, versionTable) {
+		t.Fatal("Version created the version table")
+	}
+
+	migrations, err := Load(fstest.MapFS{
+		"001_users.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n-- +goose Down\nDROP TABLE users;\n")},
+		"002_vacuum.sql": {Data: []byte("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE notes (id INTEGER);\nVACUUM;\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Up(ctx, db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(applied, []int64{1, 2}) {
+		t.Errorf("applied = %v, want [1 2]", applied)
+	}
+	if !tableExists(t, db, "users") || !tableExists(t, db, "notes") {
+		t.Error("migration tables were not created")
+	}
+
+	applied, err = Up(ctx, db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second Up applied %v", applied)
+	}
+
+	version, err = Version(ctx, db)
+	if err != nil || version != 2 {
+		t.Errorf("Version = %d, %v; want 2", version, err)
+	}
+}
+
+func TestUpRollsBackAFailedMigration(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := Load(fstest.MapFS{
+		"001_ok.sql":     {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n")},
+		"002_broken.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\nNOT SQL;\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := Up(ctx, db, migrations)
+	if err == nil || !strings.Contains(err.Error(), "002_broken.sql") {
+		t.Fatalf("err = %v, want a failure naming 002_broken.sql", err)
+	}
+	if !reflect.DeepEqual(applied, []int64{1}) {
+		t.Errorf("applied = %v, want [1]", applied)
+	}
+	if tableExists(t, db, "b") {
+		t.Error("statements of the failed migration were kept")
+	}
+	if version, err := Version(ctx, db); err != nil || version != 1 {
+		t.Errorf("Version = %d, %v; want 1", version, err)
+	}
+}
@@ -0,0 +1,140 @@
+package wishlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+)
+
+// Render writes a wishlist in DIM's text format.
+func Render(w io.Writer, list Wishlist) error {
+	out := bufio.NewWriter(w)
+	if list.Title != "" {
+		fmt.Fprintf(out, "title:%s\n", cleanLine(list.Title))
+	}
+	if list.Description != "" {
+		fmt.Fprintf(out, "description:%s\n", cleanLine(list.Description))
+	}
+	fmt.Fprintln(out)
+
+	for _, roll := range list.Rolls {
+		itemHash := roll.ItemHash
+		if roll.Trash {
+			itemHash = -itemHash
+		}
+		perks := make([]string, len(roll.PerkHashes))
+		for i, perkHash := range roll.PerkHashes {
+			perks[i] = strconv.FormatInt(perkHash, 10)
+		}
+		fmt.Fprintf(out, "dimwishlist:item=%d&perks=%s", itemHash, strings.Join(perks, ","))
+		if roll.Notes != "" {
+			fmt.Fprintf(out, "#notes:%s", cleanLine(roll.Notes))
+		}
+		fmt.Fprintln(out)
+	}
+
+	return out.Flush()
+}
+
+// FromCatalog turns the tier list into wishlist rolls. A catalog roll counts
+// when it has any two desired perks, so every pair of desired perks becomes a
+// line for every version of the weapon; a weapon with a single desired perk
+// gets one line per hash of it. Perk hashes come from the curated
+// WeaponDesiredPerks, limited to base hashes the version can actually roll
+// since DIM treats enhanced perks as their base version.
+func FromCatalog(catalog []generator.WeaponPerkInput) Wishlist {
+	list := Wishlist{
+		Title:       "D2Loot Tier List",
+		Description: "Weapons and perk combinations ranked by D2Loot buckets",
+	}
+
+	for _, weapon := range catalog {
+		notes := fmt.Sprintf("D2Loot %s (rank %s)", weapon.Bucket, weapon.Rank)
+		if weapon.Description != "" {
+			notes += ": " + weapon.Description
+		}
+		if len(weapon.DesiredPerks) > 0 {
+			notes += " | Desired perks: " + strings.Join(weapon.DesiredPerks, ", ")
+		}
+
+		weaponHashes := append([]int64(nil), constants.WeaponHashes[weapon.WeaponName]...)
+		sort.Slice(weaponHashes, func(i, j int) bool { return weaponHashes[i] < weaponHashes[j] })
+		desired := constants.WeaponDesiredPerks[weapon.WeaponName]
+
+		for _, weaponHash := range weaponHashes {
+			possible := make(map[int64]struct{})
+			for _, perkHash := range constants.WeaponPossiblePerks[weaponHash] {
+				possible[perkHash] = struct{}{}
+			}
+
+			// Base perk hashes per desired perk name that this version can roll
+			perkOptions := [][]int64{}
+			for _, perkName := range weapon.DesiredPerks {
+				options := basePerkHashes(perkName, desired, possible)
+				if len(options) > 0 {
+					perkOptions = append(perkOptions, options)
+				}
+			}
+
+			if len(perkOptions) == 1 {
+				for _, perkHash := range perkOptions[0] {
+					list.Rolls = append(list.Rolls, Roll{
+						ItemHash:   weaponHash,
+						PerkHashes: []int64{perkHash},
+						Notes:      notes,
+					})
+				}
+				continue
+			}
+			for i := 0; i < len(perkOptions); i++ {
+				for j := i + 1; j < len(perkOptions); j++ {
+					for _, first := range perkOptions[i] {
+						for _, second := range perkOptions[j] {
+							list.Rolls = append(list.Rolls, Roll{
+								ItemHash:   weaponHash,
+								PerkHashes: []int64{first, second},
+								Notes:      notes,
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+// basePerkHashes returns the non-enhanced hashes among desired that are named
+// perkName and appear in possible.
+func basePerkHashes(perkName string, desired []int64, possible map[int64]struct{}) []int64 {
+	seen := make(map[int64]struct{})
+	hashes := []int64{}
+	for _, perkHash := range desired {
+		if _, dup := seen[perkHash]; dup {
+			continue
+		}
+		seen[perkHash] = struct{}{}
+		name := constants.PerkHashesReverse[perkHash]
+		if strings.HasPrefix(name, "Enhanced ") || !strings.EqualFold(name, perkName) {
+			continue
+		}
+		if _, canRoll := possible[perkHash]; !canRoll {
+			continue
+		}
+		hashes = append(hashes, perkHash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// cleanLine keeps free text on a single wishlist line.
+func cleanLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
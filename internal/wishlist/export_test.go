@@ -0,0 +1,93 @@
+package wishlist
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+)
+
+// curatedWeapon builds a catalog entry from the generated data for a weapon
+// with at least two desired perks, naming them the way the tier list does.
+func curatedWeapon(t *testing.T) (generator.WeaponPerkInput, map[int64]struct{}) {
+	t.Helper()
+	names := make([]string, 0, len(constants.WeaponDesiredPerks))
+	for name := range constants.WeaponDesiredPerks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if len(constants.WeaponHashes[name]) == 0 {
+			continue
+		}
+		desired := make(map[int64]struct{})
+		perkNames := []string{}
+		for _, perkHash := range constants.WeaponDesiredPerks[name] {
+			desired[perkHash] = struct{}{}
+			perkName := strings.TrimPrefix(constants.PerkHashesReverse[perkHash], "Enhanced ")
+			if perkName != "" && !containsFold(perkNames, perkName) {
+				perkNames = append(perkNames, perkName)
+			}
+		}
+		if len(perkNames) >= 2 {
+			return generator.WeaponPerkInput{
+				WeaponName:   name,
+				DesiredPerks: perkNames,
+				Description:  "test entry",
+				Bucket:       "Add Clear",
+				Rank:         "1",
+			}, desired
+		}
+	}
+	t.Skip("no weapon in the generated data has two desired perks")
+	return generator.WeaponPerkInput{}, nil
+}
+
+func TestFromCatalogExportsDesiredPerks(t *testing.T) {
+	weapon, desired := curatedWeapon(t)
+
+	list := FromCatalog([]generator.WeaponPerkInput{weapon})
+	if len(list.Rolls) == 0 {
+		t.Fatalf("no rolls exported for %s", weapon.WeaponName)
+	}
+
+	versions := make(map[int64]struct{})
+	for _, weaponHash := range constants.WeaponHashes[weapon.WeaponName] {
+		versions[weaponHash] = struct{}{}
+	}
+	exportedNames := make(map[string]struct{})
+	for _, roll := range list.Rolls {
+		if _, known := versions[roll.ItemHash]; !known {
+			t.Errorf("roll for unknown item %d", roll.ItemHash)
+		}
+		if len(roll.PerkHashes) == 0 || len(roll.PerkHashes) > 2 {
+			t.Errorf("roll %v is neither a desired perk pair nor a single desired perk", roll.PerkHashes)
+		}
+		for _, perkHash := range roll.PerkHashes {
+			if _, curated := desired[perkHash]; !curated {
+				t.Errorf("perk %d is not one of the curated desired perks", perkHash)
+			}
+			name := constants.PerkHashesReverse[perkHash]
+			if strings.HasPrefix(name, "Enhanced ") {
+				t.Errorf("enhanced perk %d exported", perkHash)
+			}
+			exportedNames[strings.ToLower(name)] = struct{}{}
+		}
+		if !strings.Contains(roll.Notes, "Desired perks: "+strings.Join(weapon.DesiredPerks, ", ")) {
+			t.Errorf("notes %q do not list the desired perks", roll.Notes)
+		}
+	}
+	if len(exportedNames) < 2 {
+		t.Errorf("only %d distinct desired perks exported", len(exportedNames))
+	}
+}
+
+func TestFromCatalogSkipsUnknownWeapons(t *testing.T) {
+	list := FromCatalog([]generator.WeaponPerkInput{{WeaponName: "Not A Real Weapon", DesiredPerks: []string{"Firefly", "Rampage"}}})
+	if len(list.Rolls) != 0 {
+		t.Errorf("exported %d rolls for an unknown weapon", len(list.Rolls))
+	}
+}
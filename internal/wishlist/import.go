@@ -0,0 +1,155 @@
+package wishlist
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+)
+
+// Conflict records a catalog entry whose desired perks differ from a wishlist.
+type Conflict struct {
+	WeaponName    string
+	CatalogPerks  []string
+	WishlistPerks []string
+}
+
+// ImportResult summarises how a wishlist was merged into the catalog.
+type ImportResult struct {
+	Weapons   []generator.WeaponPerkInput
+	Added     []string
+	Updated   []string
+	Conflicts []Conflict
+	Skipped   []string // Weapons or hashes that could not be resolved or placed
+}
+
+// ImportOptions controls how wishlist rolls become catalog entries.
+type ImportOptions struct {
+	// Bucket and Rank are used for weapons not in the catalog yet. Without a
+	// bucket, new weapons are skipped since the rating requires one.
+	Bucket string
+	Rank   string
+	// Overwrite replaces the desired perks of conflicting catalog entries.
+	Overwrite bool
+}
+
+// Resolver looks up names in the manifest.
+type Resolver interface {
+	// WeaponName returns the display name of a weapon hash.
+	WeaponName(itemHash int64) (string, bool)
+	// TraitName returns the name of a trait perk hash, with any "Enhanced "
+	// prefix removed. Barrels, magazines and other columns are not traits.
+	TraitName(perkHash int64) (string, bool)
+}
+
+// Import merges the wanted rolls of a wishlist into catalog entries. Trait perks
+// from every roll of a weapon are combined into its desired perks.
+func Import(list Wishlist, catalog []generator.WeaponPerkInput, resolver Resolver, options ImportOptions) ImportResult {
+	result := ImportResult{}
+
+	// Collect desired trait names per weapon name, keeping first-seen order
+	wishlistPerks := make(map[string][]string)
+	notes := make(map[string]string)
+	order := []string{}
+	skipped := make(map[string]struct{})
+	for _, roll := range list.Rolls {
+		if roll.Trash || roll.ItemHash == AnyItem {
+			continue
+		}
+		weaponName, found := resolver.WeaponName(roll.ItemHash)
+		if !found {
+			skipped["unknown item "+strconv.FormatInt(roll.ItemHash, 10)] = struct{}{}
+			continue
+		}
+		if _, seen := wishlistPerks[weaponName]; !seen {
+			order = append(order, weaponName)
+			wishlistPerks[weaponName] = []string{}
+			notes[weaponName] = roll.Notes
+		}
+		for _, perkHash := range roll.PerkHashes {
+			traitName, isTrait := resolver.TraitName(perkHash)
+			if !isTrait || containsFold(wishlistPerks[weaponName], traitName) {
+				continue
+			}
+			wishlistPerks[weaponName] = append(wishlistPerks[weaponName], traitName)
+		}
+	}
+
+	catalogIndex := make(map[string]int)
+	result.Weapons = append(result.Weapons, catalog...)
+	for i, weapon := range result.Weapons {
+		catalogIndex[strings.ToLower(weapon.WeaponName)] = i
+	}
+
+	for _, weaponName := range order {
+		perks := wishlistPerks[weaponName]
+		if len(perks) == 0 {
+			skipped[weaponName+" (no trait perks)"] = struct{}{}
+			continue
+		}
+
+		if i, exists := catalogIndex[strings.ToLower(weaponName)]; exists {
+			existing := result.Weapons[i]
+			if samePerks(existing.DesiredPerks, perks) {
+				continue
+			}
+			result.Conflicts = append(result.Conflicts, Conflict{
+				WeaponName:    existing.WeaponName,
+				CatalogPerks:  existing.DesiredPerks,
+				WishlistPerks: perks,
+			})
+			if options.Overwrite {
+				result.Weapons[i].DesiredPerks = perks
+				result.Updated = append(result.Updated, existing.WeaponName)
+			}
+			continue
+		}
+
+		if options.Bucket == "" {
+			skipped[weaponName+" (not in catalog and no bucket given)"] = struct{}{}
+			continue
+		}
+		rank := options.Rank
+		if rank == "" {
+			rank = "1"
+		}
+		result.Weapons = append(result.Weapons, generator.WeaponPerkInput{
+			WeaponName:   weaponName,
+			DesiredPerks: perks,
+			Description:  notes[weaponName],
+			Bucket:       options.Bucket,
+			Rank:         rank,
+		})
+		catalogIndex[strings.ToLower(weaponName)] = len(result.Weapons) - 1
+		result.Added = append(result.Added, weaponName)
+	}
+
+	for name := range skipped {
+		result.Skipped = append(result.Skipped, name)
+	}
+	sort.Strings(result.Skipped)
+
+	return result
+}
+
+func samePerks(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, perk := range a {
+		if !containsFold(b, perk) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package wishlist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+)
+
+type fakeResolver struct {
+	weapons map[int64]string
+	traits  map[int64]string
+}
+
+func (f fakeResolver) WeaponName(itemHash int64) (string, bool) {
+	name, found := f.weapons[itemHash]
+	return name, found
+}
+
+func (f fakeResolver) TraitName(perkHash int64) (string, bool) {
+	name, found := f.traits[perkHash]
+	return name, found
+}
+
+var testResolver = fakeResolver{
+	weapons: map[int64]string{1: "Fatebringer", 2: "Austringer", 3: "Ikelos SMG"},
+	traits:  map[int64]string{10: "Explosive Payload", 11: "Firefly", 12: "Kill Clip", 13: "Rampage"},
+}
+
+func TestImport(t *testing.T) {
+	list := Wishlist{Rolls: []Roll{
+		{ItemHash: 1, PerkHashes: []int64{10, 11, 99}, Notes: "classic"},
+		{ItemHash: 1, PerkHashes: []int64{11, 12}},
+		{ItemHash: 2, PerkHashes: []int64{12, 13}, Notes: "new hand cannon"},
+		{ItemHash: 3, PerkHashes: []int64{99}},
+		{ItemHash: 3, PerkHashes: []int64{10}, Trash: true},
+		{ItemHash: AnyItem, PerkHashes: []int64{10}},
+		{ItemHash: 404, PerkHashes: []int64{10}},
+	}}
+	catalog := []generator.WeaponPerkInput{
+		{WeaponName: "Fatebringer", DesiredPerks: []string{"Explosive Payload", "Firefly"}, Bucket: "Add Clear", Rank: "1"},
+	}
+
+	tests := []struct {
+		name          string
+		options       ImportOptions
+		wantWeapons   []generator.WeaponPerkInput
+		wantAdded     []string
+		wantUpdated   []string
+		wantConflicts int
+		wantSkipped   []string
+	}{
+		{
+			name: "report conflicts and skip new weapons without a bucket",
+			wantWeapons: []generator.WeaponPerkInput{
+				{WeaponName: "Fatebringer", DesiredPerks: []string{"Explosive Payload", "Firefly"}, Bucket: "Add Clear", Rank: "1"},
+			},
+			wantConflicts: 1,
+			wantSkipped: []string{
+				"Austringer (not in catalog and no bucket given)",
+				"Ikelos SMG (no trait perks)",
+				"unknown item 404",
+			},
+		},
+		{
+			name:    "overwrite and add with a bucket",
+			options: ImportOptions{Bucket: "Boss DPS", Overwrite: true},
+			wantWeapons: []generator.WeaponPerkInput{
+				{WeaponName: "Fatebringer", DesiredPerks: []string{"Explosive Payload", "Firefly", "Kill Clip"}, Bucket: "Add Clear", Rank: "1"},
+				{WeaponName: "Austringer", DesiredPerks: []string{"Kill Clip", "Rampage"}, Description: "new hand cannon", Bucket: "Boss DPS", Rank: "1"},
+			},
+			wantAdded:     []string{"Austringer"},
+			wantUpdated:   []string{"Fatebringer"},
+			wantConflicts: 1,
+			wantSkipped:   []string{"Ikelos SMG (no trait perks)", "unknown item 404"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Import(list, catalog, testResolver, tt.options)
+			if !reflect.DeepEqual(result.Weapons, tt.wantWeapons) {
+				t.Errorf("weapons =\n%+v\nwant\n%+v", result.Weapons, tt.wantWeapons)
+			}
+			if !reflect.DeepEqual(result.Added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", result.Added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(result.Updated, tt.wantUpdated) {
+				t.Errorf("updated = %v, want %v", result.Updated, tt.wantUpdated)
+			}
+			if len(result.Conflicts) != tt.wantConflicts {
+				t.Errorf("conflicts = %+v, want %d", result.Conflicts, tt.wantConflicts)
+			}
+			if !reflect.DeepEqual(result.Skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", result.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+
+	if catalog[0].DesiredPerks[len(catalog[0].DesiredPerks)-1] != "Firefly" {
+		t.Error("Import modified the catalog it was given")
+	}
+}
+
+func TestImportKeepsMatchingEntries(t *testing.T) {
+	list := Wishlist{Rolls: []Roll{{ItemHash: 1, PerkHashes: []int64{11, 10}}}}
+	catalog := []generator.WeaponPerkInput{
+		{WeaponName: "Fatebringer", DesiredPerks: []string{"explosive payload", "Firefly"}, Bucket: "Add Clear", Rank: "1"},
+	}
+
+	result := Import(list, catalog, testResolver, ImportOptions{Overwrite: true})
+	if len(result.Conflicts) != 0 || len(result.Updated) != 0 {
+		t.Errorf("same perks in another order were treated as a conflict: %+v", result)
+	}
+}
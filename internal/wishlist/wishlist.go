@@ -0,0 +1,114 @@
+package wishlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AnyItem is DIM's wildcard item hash: the roll applies to every weapon.
+const AnyItem = -69420
+
+// Wishlist is a parsed DIM wishlist file.
+type Wishlist struct {
+	Title       string
+	Description string
+	Rolls       []Roll
+}
+
+// Roll is one dimwishlist line.
+type Roll struct {
+	ItemHash   int64
+	PerkHashes []int64
+	Notes      string
+	Trash      bool // Negative item hashes mark rolls to avoid
+}
+
+// Parse reads a DIM wishlist. Block notes ("//notes:") apply to the rolls that
+// follow them until the next blank line; inline "#notes:" override them.
+func Parse(r io.Reader) (Wishlist, error) {
+	var list Wishlist
+	blockNotes := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			blockNotes = ""
+		case strings.HasPrefix(line, "title:"):
+			list.Title = strings.TrimSpace(strings.TrimPrefix(line, "title:"))
+		case strings.HasPrefix(line, "description:"):
+			list.Description = strings.TrimSpace(strings.TrimPrefix(line, "description:"))
+		case strings.HasPrefix(line, "//notes:"):
+			blockNotes = strings.TrimSpace(strings.TrimPrefix(line, "//notes:"))
+		case strings.HasPrefix(line, "dimwishlist:"):
+			roll, err := parseRoll(strings.TrimPrefix(line, "dimwishlist:"))
+			if err != nil {
+				return Wishlist{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if roll.Notes == "" {
+				roll.Notes = blockNotes
+			}
+			list.Rolls = append(list.Rolls, roll)
+		}
+		// Any other line is a comment
+	}
+	if err := scanner.Err(); err != nil {
+		return Wishlist{}, err
+	}
+
+	return list, nil
+}
+
+// parseRoll parses "item=123&perks=1,2,3#notes:text".
+func parseRoll(value string) (Roll, error) {
+	var roll Roll
+
+	if i := strings.Index(value, "#notes:"); i != -1 {
+		roll.Notes = strings.TrimSpace(value[i+len("#notes:"):])
+		value = value[:i]
+	}
+
+	for _, part := range strings.Split(value, "&") {
+		key, raw, found := strings.Cut(part, "=")
+		if !found {
+			return Roll{}, fmt.Errorf("malformed field %q", part)
+		}
+		switch key {
+		case "item":
+			hash, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return Roll{}, fmt.Errorf("invalid item hash %q", raw)
+			}
+			if hash < 0 && hash != AnyItem {
+				roll.Trash = true
+				hash = -hash
+			}
+			roll.ItemHash = hash
+		case "perks":
+			for _, rawPerk := range strings.Split(raw, ",") {
+				rawPerk = strings.TrimSpace(rawPerk)
+				if rawPerk == "" {
+					continue
+				}
+				perkHash, err := strconv.ParseInt(rawPerk, 10, 64)
+				if err != nil {
+					return Roll{}, fmt.Errorf("invalid perk hash %q", rawPerk)
+				}
+				roll.PerkHashes = append(roll.PerkHashes, perkHash)
+			}
+		}
+	}
+
+	if roll.ItemHash == 0 {
+		return Roll{}, fmt.Errorf("missing item hash")
+	}
+	return roll, nil
+}
@@ -0,0 +1,93 @@
+package wishlist
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `title:My List
+description:Rolls worth keeping
+
+// a comment line
+//notes:PvE god roll
+dimwishlist:item=123&perks=1,2
+dimwishlist:item=123&perks=3#notes:inline wins
+
+dimwishlist:item=-456&perks=4
+dimwishlist:item=-69420&perks=5,6
+`
+	list, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Wishlist{
+		Title:       "My List",
+		Description: "Rolls worth keeping",
+		Rolls: []Roll{
+			{ItemHash: 123, PerkHashes: []int64{1, 2}, Notes: "PvE god roll"},
+			{ItemHash: 123, PerkHashes: []int64{3}, Notes: "inline wins"},
+			{ItemHash: 456, PerkHashes: []int64{4}, Trash: true},
+			{ItemHash: AnyItem, PerkHashes: []int64{5, 6}},
+		},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", list, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "bad item hash", line: "dimwishlist:item=abc&perks=1"},
+		{name: "bad perk hash", line: "dimwishlist:item=1&perks=1,x"},
+		{name: "missing item", line: "dimwishlist:perks=1,2"},
+		{name: "malformed field", line: "dimwishlist:item=1&perks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader("title:x\n" + tt.line + "\n"))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), "line 2") {
+				t.Errorf("error %q does not name the line", err)
+			}
+		})
+	}
+}
+
+func TestRenderParseRoundTrip(t *testing.T) {
+	list := Wishlist{
+		Title:       "D2Loot Tier List",
+		Description: "Weapons and perk combinations\nranked by buckets",
+		Rolls: []Roll{
+			{ItemHash: 123, PerkHashes: []int64{1, 2}, Notes: "Add Clear (rank 1): great   for\tpve"},
+			{ItemHash: 456, PerkHashes: []int64{3}, Trash: true},
+			{ItemHash: 789, PerkHashes: []int64{4, 5}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := list
+	want.Description = "Weapons and perk combinations ranked by buckets"
+	want.Rolls = append([]Roll(nil), list.Rolls...)
+	want.Rolls[0].Notes = "Add Clear (rank 1): great for pve"
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", parsed, want)
+	}
+}
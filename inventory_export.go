@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportRow is one owned instance of a catalog weapon in the rated inventory export.
+type ExportRow struct {
+	InstanceID    string   `json:"instanceId"`
+	ItemHash      int64    `json:"itemHash"`
+	WeaponName    string   `json:"weaponName"`
+	WeaponType    string   `json:"weaponType"`
+	Bucket        string   `json:"bucket"`
+	Rank          int      `json:"rank"`
+	Location      string   `json:"location"`
+	CharacterID   string   `json:"characterId"`
+	MatchedPerks  []string `json:"matchedPerks"`
+	Qualifies     bool     `json:"qualifies"`  // Has enough desired perks to count for its bucket
+	IsBestRoll    bool     `json:"isBestRoll"` // The copy the rating is based on
+	Crafted       bool     `json:"crafted"`
+	EnhancedPerks []string `json:"enhancedPerks"`
+	Power         int      `json:"power"`
+	Masterwork    string   `json:"masterwork"`
+	Locked        bool     `json:"locked"`
+	Points        float64  `json:"points"` // Weapon points, attributed to its best roll only
+}
+
+var exportCSVHeader = []string{
+	"instance_id", "item_hash", "weapon_name", "weapon_type", "bucket", "rank",
+	"location", "character_id", "matched_perks", "qualifies", "is_best_roll",
+	"crafted", "enhanced_perks", "power", "masterwork", "locked", "points",
+}
+
+// buildExportRows combines the per-instance evaluation with the points the
+// rating assigns to each weapon.
+func buildExportRows(evaluation inventoryEvaluation, rating ResponseData) []ExportRow {
+	weaponPoints := make(map[string]float64)
+	weaponTypes := make(map[string]string)
+	for _, detail := range rating.WeaponDetails {
+		weaponPoints[detail.WeaponName] = detail.Points
+		weaponTypes[detail.WeaponName] = detail.WeaponType
+	}
+
+	rows := []ExportRow{}
+	for _, instance := range evaluation.Instances {
+		best, owned := evaluation.BestRolls[instance.Weapon.WeaponName]
+		isBest := owned && best.InstanceID == instance.Item.ItemInstanceID
+
+		row := ExportRow{
+			InstanceID:    instance.Item.ItemInstanceID,
+			ItemHash:      instance.Item.ItemHash,
+			WeaponName:    instance.Weapon.WeaponName,
+			WeaponType:    weaponTypes[instance.Weapon.WeaponName],
+			Bucket:        instance.Weapon.Bucket,
+			Rank:          instance.Weapon.Rank,
+			Location:      instance.Item.Location,
+			CharacterID:   instance.Item.CharacterID,
+			MatchedPerks:  instance.MatchedPerks,
+			Qualifies:     instance.Qualifies,
+			IsBestRoll:    isBest,
+			Crafted:       instance.Roll.Crafted,
+			EnhancedPerks: instance.Roll.EnhancedPerks,
+			Power:         instance.Roll.Instance.Power,
+			Masterwork:    instance.Roll.Instance.Masterwork,
+			Locked:        instance.Roll.Instance.Locked,
+		}
+		if row.EnhancedPerks == nil {
+			row.EnhancedPerks = []string{}
+		}
+		if isBest {
+			row.Points = weaponPoints[instance.Weapon.WeaponName]
+		}
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Bucket != rows[j].Bucket {
+			return rows[i].Bucket < rows[j].Bucket
+		}
+		if rows[i].WeaponName != rows[j].WeaponName {
+			return rows[i].WeaponName < rows[j].WeaponName
+		}
+		return rows[i].Points > rows[j].Points
+	})
+
+	return rows
+}
+
+// writeExportCSV streams rows as CSV, flushing after each row.
+func writeExportCSV(w io.Writer, rows []ExportRow, flush func()) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.InstanceID,
+			strconv.FormatInt(row.ItemHash, 10),
+			row.WeaponName,
+			row.WeaponType,
+			row.Bucket,
+			strconv.Itoa(row.Rank),
+			row.Location,
+			row.CharacterID,
+			strings.Join(row.MatchedPerks, ";"),
+			strconv.FormatBool(row.Qualifies),
+			strconv.FormatBool(row.IsBestRoll),
+			strconv.FormatBool(row.Crafted),
+			strings.Join(row.EnhancedPerks, ";"),
+			strconv.Itoa(row.Power),
+			row.Masterwork,
+			strconv.FormatBool(row.Locked),
+			strconv.FormatFloat(row.Points, 'f', 2, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		flush()
+	}
+	return nil
+}
+
+// writeExportNDJSON streams rows as newline-delimited JSON, flushing after each row.
+func writeExportNDJSON(w io.Writer, rows []ExportRow, flush func()) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		flush()
+	}
+	return nil
+}
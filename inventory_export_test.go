@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testExportRows evaluates two copies of one weapon, the second being the
+// best roll, plus a copy of another weapon that does not qualify.
+func testExportRows() []ExportRow {
+	autumn := WeaponDefinition{WeaponName: "Anonymous Autumn", Bucket: "Heavy", Rank: 1}
+	slammer := WeaponDefinition{WeaponName: "The Slammer", Bucket: "Heavy", Rank: 2}
+	evaluation := inventoryEvaluation{
+		Instances: []ratedInstance{
+			{
+				Item:         ownedItem{InventoryItem: InventoryItem{ItemHash: 1051949956, ItemInstanceID: "1"}, Location: "vault"},
+				Weapon:       autumn,
+				Roll:         rollInfo{InstanceID: "1", MatchingPerks: 2},
+				MatchedPerks: []string{"Desperation", "Repulsor Force"},
+				Qualifies:    true,
+			},
+			{
+				Item:         ownedItem{InventoryItem: InventoryItem{ItemHash: 1051949956, ItemInstanceID: "2"}, CharacterID: "42", Location: "equipped"},
+				Weapon:       autumn,
+				Roll:         rollInfo{InstanceID: "2", MatchingPerks: 2, Crafted: true, Instance: InstanceDetail{Power: 2000, Masterwork: "Handling", Locked: true}},
+				MatchedPerks: []string{"Desperation", "Repulsor Force"},
+				Qualifies:    true,
+			},
+			{
+				Item:         ownedItem{InventoryItem: InventoryItem{ItemHash: 2889501828, ItemInstanceID: "3"}, Location: "vault"},
+				Weapon:       slammer,
+				MatchedPerks: []string{},
+			},
+		},
+		BestRolls: map[string]rollInfo{autumn.WeaponName: {InstanceID: "2"}},
+	}
+	rating := ResponseData{WeaponDetails: []WeaponDetail{
+		{WeaponName: autumn.WeaponName, WeaponType: "Rocket Launcher", Points: 10},
+		{WeaponName: slammer.WeaponName, WeaponType: "Sword", Points: 5},
+	}}
+	return buildExportRows(evaluation, rating)
+}
+
+func TestBuildExportRowsAttributesPointsToTheBestRoll(t *testing.T) {
+	rows := testExportRows()
+
+	type summary struct {
+		InstanceID string
+		IsBestRoll bool
+		Points     float64
+	}
+	var got []summary
+	for _, row := range rows {
+		got = append(got, summary{row.InstanceID, row.IsBestRoll, row.Points})
+	}
+	want := []summary{
+		{InstanceID: "2", IsBestRoll: true, Points: 10},
+		{InstanceID: "1", IsBestRoll: false, Points: 0},
+		{InstanceID: "3", IsBestRoll: false, Points: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %+v, want %+v", got, want)
+	}
+	if rows[0].WeaponType != "Rocket Launcher" || rows[0].EnhancedPerks == nil {
+		t.Errorf("best roll row = %+v, want the weapon type and an empty enhanced perk list", rows[0])
+	}
+}
+
+func TestWriteExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	flushes := 0
+	if err := writeExportCSV(&buf, testExportRows()[:1], func() { flushes++ }); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want the header and one row", len(records))
+	}
+	if !reflect.DeepEqual(records[0], exportCSVHeader) {
+		t.Errorf("header = %v, want %v", records[0], exportCSVHeader)
+	}
+	want := []string{
+		"2", "1051949956", "Anonymous Autumn", "Rocket Launcher", "Heavy", "1",
+		"equipped", "42", "Desperation;Repulsor Force", "true", "true",
+		"true", "", "2000", "Handling", "true", "10.00",
+	}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Errorf("row = %v, want %v", records[1], want)
+	}
+	if flushes != 1 {
+		t.Errorf("flushed %d times, want once per row", flushes)
+	}
+}
+
+func TestWriteExportNDJSON(t *testing.T) {
+	rows := testExportRows()
+	var buf bytes.Buffer
+	if err := writeExportNDJSON(&buf, rows, func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var row ExportRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v", lines+1, err)
+		}
+		if !reflect.DeepEqual(row, rows[lines]) {
+			t.Errorf("line %d = %+v, want %+v", lines+1, row, rows[lines])
+		}
+		lines++
+	}
+	if lines != len(rows) {
+		t.Errorf("got %d lines, want %d", lines, len(rows))
+	}
+}
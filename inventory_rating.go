@@ -8,6 +8,8 @@ import (
 	"math"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/adamararcane/d2-loot-backend/cmd/constants"
 )
@@ -20,6 +22,10 @@ type WeaponDefinition struct {
 	Source       string   `json:"source"`
 	Bucket       string   `json:"bucket"`
 	Rank         int      `json:"rank,string"` // Parses "rank": "1" as integer 1
+	// PreferredMasterworks lists masterwork stats that make a roll better (optional)
+	PreferredMasterworks []string `json:"preferredMasterworks,omitempty"`
+	// MinStats maps stat names to the minimum value a good roll needs (optional)
+	MinStats map[string]int `json:"minStats,omitempty"`
 }
 
 // PerkWeights assigns weights to desired perks (optional).
@@ -31,6 +37,195 @@ var PerkWeights = map[string]float64{
 	// Add other perks and their weights as needed
 }
 
+// CraftedWeaponBonus is added to a weapon's points when its best roll is crafted.
+var CraftedWeaponBonus = 0.5
+
+// EnhancedPerkBonus is added for each active enhanced desired perk on the best roll.
+var EnhancedPerkBonus = 0.25
+
+// Bungie ItemState flags
+const (
+	itemStateLocked     = 1
+	itemStateMasterwork = 4
+	itemStateCrafted    = 8
+)
+
+// rollInfo captures what made an owned instance of a catalog weapon valuable.
+type rollInfo struct {
+	InstanceID          string
+	MatchingPerks       int
+	Crafted             bool
+	EnhancedPerks       []string
+	Instance            InstanceDetail
+	PreferredMasterwork bool
+}
+
+// bonus returns the crafted and enhanced perk bonus for this roll.
+func (r rollInfo) bonus() float64 {
+	bonus := EnhancedPerkBonus * float64(len(r.EnhancedPerks))
+	if r.Crafted {
+		bonus += CraftedWeaponBonus
+	}
+	return bonus
+}
+
+// betterThan orders rolls by matching perks first, then by meeting the catalog's
+// minimum stats, then by preferred masterwork and finally by crafted/enhanced bonus.
+func (r rollInfo) betterThan(other rollInfo) bool {
+	if r.MatchingPerks != other.MatchingPerks {
+		return r.MatchingPerks > other.MatchingPerks
+	}
+	if r.Instance.MeetsMinStats != other.Instance.MeetsMinStats {
+		return r.Instance.MeetsMinStats
+	}
+	if r.PreferredMasterwork != other.PreferredMasterwork {
+		return r.PreferredMasterwork
+	}
+	if r.bonus() != other.bonus() {
+		return r.bonus() > other.bonus()
+	}
+	return r.Instance.Power > other.Instance.Power
+}
+
+// masterworkStat returns the stat boosted by an instance's masterwork plug, read
+// from the manifest plug name (e.g. "Masterworked: Handling").
+func masterworkStat(socketsData ItemSockets) string {
+	for _, socket := range socketsData.Sockets {
+		plug, found := items[strconv.FormatInt(socket.PlugHash, 10)]
+		if !found || plug.Plug == nil {
+			continue
+		}
+		if !strings.Contains(plug.Plug.PlugCategoryIdentifier, "masterworks.stat") {
+			continue
+		}
+		name := plug.DisplayProperties.Name
+		if i := strings.LastIndex(name, ": "); i != -1 {
+			return name[i+2:]
+		}
+		return name
+	}
+	return ""
+}
+
+// describeInstance builds the instance detail (components 300 and 304) for an
+// owned copy of a catalog weapon and checks it against the catalog's stat rules.
+func describeInstance(profileData ProfileData, item InventoryItem, socketsData ItemSockets, weapon WeaponDefinition) (InstanceDetail, bool) {
+	detail := InstanceDetail{
+		InstanceID:     item.ItemInstanceID,
+		Masterwork:     masterworkStat(socketsData),
+		IsMasterworked: item.State&itemStateMasterwork != 0,
+		Locked:         item.State&itemStateLocked != 0,
+		Stats:          map[string]int{},
+		MeetsMinStats:  true,
+	}
+
+	if instance, exists := profileData.Response.ItemComponents.Instances.Data[item.ItemInstanceID]; exists {
+		detail.Power = instance.PrimaryStat.Value
+		if damageType, found := damageTypes[strconv.FormatInt(instance.DamageTypeHash, 10)]; found {
+			detail.DamageType = damageType.DisplayProperties.Name
+		} else {
+			detail.DamageType = damageTypeNames[instance.DamageType]
+		}
+	}
+
+	if itemStats, exists := profileData.Response.ItemComponents.Stats.Data[item.ItemInstanceID]; exists {
+		for _, stat := range itemStats.Stats {
+			if name := GetStatName(stat.StatHash); name != "" {
+				detail.Stats[name] = stat.Value
+			}
+		}
+	}
+
+	for statName, minimum := range weapon.MinStats {
+		if detail.Stats[statName] < minimum {
+			detail.MeetsMinStats = false
+			break
+		}
+	}
+
+	preferredMasterwork := false
+	for _, preferred := range weapon.PreferredMasterworks {
+		if strings.EqualFold(preferred, detail.Masterwork) {
+			preferredMasterwork = true
+			break
+		}
+	}
+
+	return detail, preferredMasterwork
+}
+
+// isEnhancedPerk reports whether a plug is the enhanced version of a perk,
+// using the manifest type name and falling back to the generated perk name.
+func isEnhancedPerk(plugHash int64) bool {
+	if plug, found := items[strconv.FormatInt(plugHash, 10)]; found {
+		if strings.HasPrefix(plug.ItemTypeDisplayName, "Enhanced") {
+			return true
+		}
+		return strings.HasPrefix(plug.DisplayProperties.Name, "Enhanced ")
+	}
+	return strings.HasPrefix(constants.PerkHashesReverse[plugHash], "Enhanced ")
+}
+
+// plugObjectivesComplete reports whether a plug's objectives (component 309) are
+// all complete. Enhanced perks on crafted weapons stay inactive until the weapon
+// reaches the required level, which shows up as an incomplete objective.
+func plugObjectivesComplete(profileData ProfileData, instanceID string, plugHash int64) bool {
+	plugObjectives, exists := profileData.Response.ItemComponents.PlugObjectives.Data[instanceID]
+	if !exists {
+		return true
+	}
+	for _, objective := range plugObjectives.ObjectivesPerPlug[strconv.FormatInt(plugHash, 10)] {
+		if !objective.Complete {
+			return false
+		}
+	}
+	return true
+}
+
+// collectSocketPlugs returns every plug a player can select in each socket of an
+// instance: the currently inserted plug from component 305 plus the enabled,
+// insertable alternatives from component 310 (reusable plugs).
+func collectSocketPlugs(profileData ProfileData, instanceID string, socketsData ItemSockets) map[int][]int64 {
+	socketPlugs := make(map[int][]int64)
+	for i, socket := range socketsData.Sockets {
+		if socket.PlugHash != 0 {
+			socketPlugs[i] = append(socketPlugs[i], socket.PlugHash)
+		}
+	}
+
+	reusablePlugs, exists := profileData.Response.ItemComponents.ReusablePlugs.Data[instanceID]
+	if !exists {
+		return socketPlugs
+	}
+	for socketIndex, plugs := range reusablePlugs.Plugs {
+		index, err := strconv.Atoi(socketIndex)
+		if err != nil {
+			continue
+		}
+		for _, plug := range plugs {
+			if plug.CanInsert && plug.Enabled {
+				socketPlugs[index] = append(socketPlugs[index], plug.PlugItemHash)
+			}
+		}
+	}
+	return socketPlugs
+}
+
+// rollBonusForBucket sums the crafted/enhanced bonuses of the best rolls of the
+// distinct weapons owned in a bucket.
+func rollBonusForBucket(ownedWeapons []WeaponDefinition, bestRolls map[string]rollInfo) float64 {
+	bonus := 0.0
+	counted := make(map[string]struct{})
+	for _, weapon := range ownedWeapons {
+		if _, done := counted[weapon.WeaponName]; done {
+			continue
+		}
+		counted[weapon.WeaponName] = struct{}{}
+		bonus += bestRolls[weapon.WeaponName].bonus()
+	}
+	return bonus
+}
+
 // loadWeaponDefinitions loads weapon definitions from a JSON file.
 func loadWeaponDefinitions(jsonPath string) ([]WeaponDefinition, error) {
 	data, err := ioutil.ReadFile(jsonPath)
@@ -84,6 +279,27 @@ func buildHashToWeaponMap(weapons []WeaponDefinition) (map[int64]WeaponDefinitio
 	return hashToWeapon, nil
 }
 
+// catalogWeaponDefinition picks the manifest definition that best represents a
+// catalog weapon, preferring a version that is not sunset.
+func catalogWeaponDefinition(weaponName string) (ItemDefinition, bool) {
+	var fallback ItemDefinition
+	found := false
+	for _, hash := range constants.WeaponHashes[weaponName] {
+		item, exists := items[strconv.FormatInt(hash, 10)]
+		if !exists {
+			continue
+		}
+		if !IsSunset(item) {
+			return item, true
+		}
+		if !found {
+			fallback = item
+			found = true
+		}
+	}
+	return fallback, found
+}
+
 // findBucketIndex returns the index of a bucket in BucketPoints based on its name.
 func findBucketIndex(bucketName string, bucketPoints []constants.BucketPoint) int {
 	for i, bp := range bucketPoints {
@@ -125,38 +341,81 @@ func calculateBucketPoints(weapons []WeaponDefinition, bp constants.BucketPoint)
 	return totalPoints
 }
 
-// rateInventory calculates the inventory rating based on the player's profile data.
-func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, error) {
-	// Step 1: Load weapon definitions from JSON
-	jsonPath := filepath.Join("cmd", "generate_constants", "weapons_and_perks.json") // Adjust the path as necessary
-	weapons, err := loadWeaponDefinitions(jsonPath)
-	if err != nil {
-		return ResponseData{}, err
-	}
+// catalogPath is where the tier list lives relative to the working directory.
+var catalogPath = filepath.Join("cmd", "generate_constants", "weapons_and_perks.json")
 
-	// Step 2: Build hashToWeapon map
-	hashToWeapon, err := buildHashToWeaponMap(weapons)
-	if err != nil {
-		return ResponseData{}, err
-	}
+// loadCatalogWeapons loads the tier list used for rating.
+func loadCatalogWeapons() ([]WeaponDefinition, error) {
+	return loadWeaponDefinitions(catalogPath)
+}
 
-	// Step 3: Collect all inventory items
-	allItems := []InventoryItem{}
-	for _, character := range profileData.Response.CharacterInventories.Data {
-		allItems = append(allItems, character.Items...)
+// Bungie inventory bucket hash for the vault
+const vaultBucketHash = 138197802
+
+// ownedItem is an inventory item together with where it currently lives.
+type ownedItem struct {
+	InventoryItem
+	CharacterID string // Empty for profile-level items such as the vault
+	Location    string // "vault", "inventory" or "equipped"
+}
+
+// collectOwnedItems flattens character inventories, the profile inventory and
+// equipped items, remembering where each item was found.
+func collectOwnedItems(profileData ProfileData) []ownedItem {
+	allItems := []ownedItem{}
+	for characterID, character := range profileData.Response.CharacterInventories.Data {
+		for _, item := range character.Items {
+			allItems = append(allItems, ownedItem{InventoryItem: item, CharacterID: characterID, Location: "inventory"})
+		}
+	}
+	for _, item := range profileData.Response.ProfileInventory.Data.Items {
+		location := "inventory"
+		if item.BucketHash == vaultBucketHash {
+			location = "vault"
+		}
+		allItems = append(allItems, ownedItem{InventoryItem: item, Location: location})
 	}
-	allItems = append(allItems, profileData.Response.ProfileInventory.Data.Items...)
-	for _, character := range profileData.Response.CharacterEquipment.Data {
-		allItems = append(allItems, character.Items...)
+	for characterID, character := range profileData.Response.CharacterEquipment.Data {
+		for _, item := range character.Items {
+			allItems = append(allItems, ownedItem{InventoryItem: item, CharacterID: characterID, Location: "equipped"})
+		}
 	}
+	return allItems
+}
 
-	// Step 4: Initialize max possible points
-	maxPossiblePoints := 0.0
-	for _, bp := range constants.BucketPoints {
-		maxPossiblePoints += bp.MaxPoints + (bp.AdditionalWeaponPts * 5) // Assuming a max of 5 additional weapons for max potential
+// ratedInstance is one owned copy of a catalog weapon with its evaluated roll.
+type ratedInstance struct {
+	Item         ownedItem
+	Weapon       WeaponDefinition
+	Roll         rollInfo
+	MatchedPerks []string // Names of the desired perks available on this copy
+	Perks        []string // Names of every known perk available on this copy
+	Qualifies    bool     // Has enough desired perks to count for its bucket
+}
+
+// inventoryEvaluation holds the per-instance results the rating is built on.
+type inventoryEvaluation struct {
+	Instances             []ratedInstance
+	OwnedWeaponsPerBucket map[string][]WeaponDefinition
+	BestRolls             map[string]rollInfo // weaponName -> best qualifying roll
+}
+
+// evaluateInventory matches every owned instance of a catalog weapon against its
+// desired perks and picks the best roll per weapon.
+func evaluateInventory(profileData ProfileData, weapons []WeaponDefinition) (inventoryEvaluation, error) {
+	evaluation := inventoryEvaluation{
+		Instances:             []ratedInstance{},
+		OwnedWeaponsPerBucket: make(map[string][]WeaponDefinition),
+		BestRolls:             make(map[string]rollInfo),
 	}
 
-	// Step 5: Build a map of desired perk hashes per weapon
+	// Build hashToWeapon map
+	hashToWeapon, err := buildHashToWeaponMap(weapons)
+	if err != nil {
+		return evaluation, err
+	}
+
+	// Build a map of desired perk hashes per weapon
 	desiredPerkHashesMap := make(map[string]map[int64]struct{}) // weaponName -> set of desired perk hashes
 	for _, weapon := range weapons {
 		desiredPerkHashesMap[weapon.WeaponName] = make(map[int64]struct{})
@@ -172,13 +431,8 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 		}
 	}
 
-	// Step 6: Initialize bucket ownership map
-	ownedWeaponsPerBucket := make(map[string][]WeaponDefinition)
-
-	/// var iconUrls []string
-
-	// Step 7: Process each inventory item
-	for _, item := range allItems {
+	// Process each inventory item
+	for _, item := range collectOwnedItems(profileData) {
 		weaponDef, isValuable := hashToWeapon[item.ItemHash]
 		if !isValuable {
 			continue // Not a valuable weapon, skip
@@ -196,15 +450,6 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			continue // No socket data for this item
 		}
 
-		// Collect perk hashes from the item
-		itemPerks := make(map[int64]struct{})
-		for _, socket := range socketsData.Sockets {
-			perkHash := socket.PlugHash
-			if _, exists := constants.PerkHashesReverse[perkHash]; exists {
-				itemPerks[perkHash] = struct{}{}
-			}
-		}
-
 		// Get desired perks for this weapon
 		weaponDesiredPerks, exists := desiredPerkHashesMap[weaponDef.WeaponName]
 		if !exists || len(weaponDesiredPerks) == 0 {
@@ -212,28 +457,130 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			continue // No desired perks defined for this weapon, skip
 		}
 
-		// Count matching perks for this instance
+		// Collect perk hashes from the item, counting each column at most once
+		// even when several of its selectable perks are desired
+		itemPerks := make(map[int64]struct{})
 		matchingPerkCount := 0
-		for perkHash := range itemPerks {
-			if _, desired := weaponDesiredPerks[perkHash]; desired {
+		for _, columnPlugs := range collectSocketPlugs(profileData, item.ItemInstanceID, socketsData) {
+			columnMatches := false
+			for _, perkHash := range columnPlugs {
+				if _, exists := constants.PerkHashesReverse[perkHash]; !exists {
+					continue
+				}
+				itemPerks[perkHash] = struct{}{}
+				if _, desired := weaponDesiredPerks[perkHash]; desired {
+					columnMatches = true
+				}
+			}
+			if columnMatches {
 				matchingPerkCount++
 			}
 		}
 
+		// Only the inserted plug (component 305) is active; enhanced perks that are
+		// merely selectable through component 310 earn no bonus
+		insertedPlugs := make(map[int64]struct{})
+		for _, socket := range socketsData.Sockets {
+			if socket.PlugHash != 0 {
+				insertedPlugs[socket.PlugHash] = struct{}{}
+			}
+		}
+
+		instance, preferredMasterwork := describeInstance(profileData, item.InventoryItem, socketsData, weaponDef)
+		rated := ratedInstance{
+			Item:   item,
+			Weapon: weaponDef,
+			Roll: rollInfo{
+				InstanceID:          item.ItemInstanceID,
+				MatchingPerks:       matchingPerkCount,
+				Crafted:             item.State&itemStateCrafted != 0,
+				Instance:            instance,
+				PreferredMasterwork: preferredMasterwork,
+			},
+			MatchedPerks: []string{},
+			Perks:        []string{},
+			Qualifies:    matchingPerkCount >= 2,
+		}
+		perkNames := make(map[string]struct{})
+		matchedNames := make(map[string]struct{})
+		for perkHash := range itemPerks {
+			perkName := constants.PerkHashesReverse[perkHash]
+			normalizedName := strings.Replace(perkName, "Enhanced ", "", 1)
+			if _, seen := perkNames[normalizedName]; !seen {
+				perkNames[normalizedName] = struct{}{}
+				rated.Perks = append(rated.Perks, normalizedName)
+			}
+			if _, desired := weaponDesiredPerks[perkHash]; !desired {
+				continue
+			}
+			if _, seen := matchedNames[normalizedName]; !seen {
+				matchedNames[normalizedName] = struct{}{}
+				rated.MatchedPerks = append(rated.MatchedPerks, normalizedName)
+			}
+			if _, inserted := insertedPlugs[perkHash]; !inserted {
+				continue
+			}
+			if isEnhancedPerk(perkHash) && plugObjectivesComplete(profileData, item.ItemInstanceID, perkHash) {
+				rated.Roll.EnhancedPerks = append(rated.Roll.EnhancedPerks, perkName)
+			}
+		}
+		sort.Strings(rated.MatchedPerks)
+		sort.Strings(rated.Perks)
+		sort.Strings(rated.Roll.EnhancedPerks)
+		evaluation.Instances = append(evaluation.Instances, rated)
+
 		// If this instance has at least two matching perks, consider it
-		if matchingPerkCount >= 2 {
-			ownedWeaponsPerBucket[bucketName] = append(ownedWeaponsPerBucket[bucketName], weaponDef)
+		if rated.Qualifies {
+			evaluation.OwnedWeaponsPerBucket[bucketName] = append(evaluation.OwnedWeaponsPerBucket[bucketName], weaponDef)
+			if best, seen := evaluation.BestRolls[weaponDef.WeaponName]; !seen || rated.Roll.betterThan(best) {
+				evaluation.BestRolls[weaponDef.WeaponName] = rated.Roll
+			}
 		}
 	}
 
-	// Step 8: Assign current points based on owned weapons per bucket
+	return evaluation, nil
+}
+
+// rateInventory calculates the global, unpersonalised inventory rating based on
+// the player's profile data.
+func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, error) {
+	// Step 1: Load weapon definitions from JSON
+	weapons, err := loadCatalogWeapons()
+	if err != nil {
+		return ResponseData{}, err
+	}
+
+	// Steps 2-7: Match owned instances against the catalog
+	evaluation, err := evaluateInventory(profileData, weapons)
+	if err != nil {
+		return ResponseData{}, err
+	}
+
+	return rateEvaluatedInventory(profileData, weapons, evaluation), nil
+}
+
+// rateEvaluatedInventory scores an already evaluated inventory so callers that
+// also need the per-instance data only evaluate it once.
+func rateEvaluatedInventory(profileData ProfileData, weapons []WeaponDefinition, evaluation inventoryEvaluation) ResponseData {
+	ownedWeaponsPerBucket := evaluation.OwnedWeaponsPerBucket
+	bestRolls := evaluation.BestRolls
+
+	// Initialize max possible points
+	maxPossiblePoints := 0.0
+	for _, bp := range constants.BucketPoints {
+		maxPossiblePoints += bp.MaxPoints + (bp.AdditionalWeaponPts * 5) // Assuming a max of 5 additional weapons for max potential
+	}
+
+	// Step 8: Assign current points based on owned weapons per bucket. The roll
+	// bonus is kept apart so potential points only compare weapon ownership.
 	currentBucketPoints := make(map[string]float64)
+	bucketRollBonus := make(map[string]float64)
 	for _, bp := range constants.BucketPoints {
 		bucketName := bp.BucketName
 		ownedWeapons, owned := ownedWeaponsPerBucket[bucketName]
 		if owned && len(ownedWeapons) > 0 {
-			currentPoints := calculateBucketPoints(ownedWeapons, bp)
-			currentBucketPoints[bucketName] = currentPoints
+			currentBucketPoints[bucketName] = calculateBucketPoints(ownedWeapons, bp)
+			bucketRollBonus[bucketName] = rollBonusForBucket(ownedWeapons, bestRolls)
 		} else {
 			currentBucketPoints[bucketName] = 0.0
 		}
@@ -265,12 +612,32 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			Obtained:         obtained,
 			Description:      weapon.Description,
 			Source:           weapon.Source,
+			EnhancedPerks:    []string{},
+		}
+
+		if roll, owned := bestRolls[weapon.WeaponName]; owned && obtained {
+			detail.Crafted = roll.Crafted
+			if roll.EnhancedPerks != nil {
+				detail.EnhancedPerks = roll.EnhancedPerks
+			}
+			bestInstance := roll.Instance
+			detail.BestInstance = &bestInstance
 		}
 
 		if detail.WeaponType == "" {
 			detail.WeaponType = "Unknown"
 		}
 
+		// Prefer manifest data over the hand-written catalog text
+		if itemDef, found := catalogWeaponDefinition(weapon.WeaponName); found {
+			detail.DamageType = GetDamageTypeName(itemDef)
+			detail.AmmoType = ammoTypeNames[itemDef.EquippingBlock.AmmoType]
+			detail.Sunset = IsSunset(itemDef)
+			if source := GetCollectibleSource(itemDef); source != "" {
+				detail.Source = source
+			}
+		}
+
 		if obtained {
 			// Current contribution of the weapon
 			ownedWeapons := ownedWeaponsPerBucket[weapon.Bucket]
@@ -331,6 +698,9 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			}
 			detail.Points += perkPoints
 
+			// Add crafted and enhanced perk bonus for the best roll
+			detail.Points += bestRolls[weapon.WeaponName].bonus()
+
 		} else {
 			// Potential points if the weapon is obtained
 			bucketName := weapon.Bucket
@@ -426,7 +796,7 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 
 	// Recalculate total points based on current bucket points
 	for _, bp := range constants.BucketPoints {
-		inventoryRating.TotalPoints += currentBucketPoints[bp.BucketName]
+		inventoryRating.TotalPoints += currentBucketPoints[bp.BucketName] + bucketRollBonus[bp.BucketName]
 	}
 
 	// Step 12: Prepare bucket details
@@ -475,6 +845,8 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 					}
 					currentPoints += additionalPoints
 				}
+
+				currentPoints += bucketRollBonus[bucketName]
 			}
 		}
 
@@ -486,6 +858,7 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			CurrentPoints:    currentPoints,
 			AdditionalCount:  additionalCount,
 			AdditionalPoints: additionalPoints,
+			Weight:           1,
 		})
 	}
 
@@ -496,10 +869,19 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 			Name:        nextImportantGun.WeaponName,
 			Icon:        "https://bungie.net" + constants.WeaponIcons[nextImportantGun.WeaponName],
 			WeaponType:  nextImportantGun.Bucket,
+			TypeName:    constants.WeaponTypes[nextImportantGun.WeaponName],
+			Bucket:      nextImportantGun.Bucket,
 			Description: nextImportantGun.Description,
 			Source:      nextImportantGun.Source,
 			Points:      maxPotentialPoints,
 		}
+		if itemDef, found := catalogWeaponDefinition(nextImportantGun.WeaponName); found {
+			nextGun.DamageType = GetDamageTypeName(itemDef)
+			nextGun.AmmoType = ammoTypeNames[itemDef.EquippingBlock.AmmoType]
+			if source := GetCollectibleSource(itemDef); source != "" {
+				nextGun.Source = source
+			}
+		}
 	}
 
 	// Step 14: Prepare the response data
@@ -511,5 +893,5 @@ func (api *apiConfig) rateInventory(profileData ProfileData) (ResponseData, erro
 		BucketDetails:    bucketDetails,
 	}
 
-	return responseData, nil
+	return responseData
 }
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+func TestRollInfoBonus(t *testing.T) {
+	tests := []struct {
+		name string
+		roll rollInfo
+		want float64
+	}{
+		{name: "plain roll", roll: rollInfo{}, want: 0},
+		{name: "crafted", roll: rollInfo{Crafted: true}, want: CraftedWeaponBonus},
+		{name: "two enhanced perks", roll: rollInfo{EnhancedPerks: []string{"Enhanced A", "Enhanced B"}}, want: 2 * EnhancedPerkBonus},
+		{name: "crafted and enhanced", roll: rollInfo{Crafted: true, EnhancedPerks: []string{"Enhanced A"}}, want: CraftedWeaponBonus + EnhancedPerkBonus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.roll.bonus(); got != tt.want {
+				t.Errorf("bonus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollBonusForBucket(t *testing.T) {
+	bestRolls := map[string]rollInfo{
+		"Crafted":  {Crafted: true},
+		"Enhanced": {EnhancedPerks: []string{"Enhanced A"}},
+		"Plain":    {},
+	}
+
+	tests := []struct {
+		name  string
+		owned []WeaponDefinition
+		want  float64
+	}{
+		{name: "nothing owned", owned: nil, want: 0},
+		{name: "plain roll", owned: []WeaponDefinition{{WeaponName: "Plain"}}, want: 0},
+		{
+			name:  "bonuses add up",
+			owned: []WeaponDefinition{{WeaponName: "Crafted"}, {WeaponName: "Enhanced"}},
+			want:  CraftedWeaponBonus + EnhancedPerkBonus,
+		},
+		{
+			name:  "copies of a weapon count once",
+			owned: []WeaponDefinition{{WeaponName: "Crafted"}, {WeaponName: "Crafted"}},
+			want:  CraftedWeaponBonus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollBonusForBucket(tt.owned, bestRolls); got != tt.want {
+				t.Errorf("rollBonusForBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// useEnhancedPlug swaps in a manifest that marks the Desperation plug
+// (525593296) as an enhanced trait.
+func useEnhancedPlug(t *testing.T) {
+	t.Helper()
+	previous := items
+	t.Cleanup(func() { items = previous })
+	items = map[string]ItemDefinition{}
+	var plug ItemDefinition
+	if err := json.Unmarshal([]byte(`{"displayProperties": {"name": "Desperation"}, "itemTypeDisplayName": "Enhanced Trait"}`), &plug); err != nil {
+		t.Fatal(err)
+	}
+	items["525593296"] = plug
+}
+
+func testProfile(t *testing.T, raw string) ProfileData {
+	t.Helper()
+	var profileData ProfileData
+	if err := json.Unmarshal([]byte(raw), &profileData); err != nil {
+		t.Fatal(err)
+	}
+	return profileData
+}
+
+func TestEvaluateInventoryCountsOnlyInsertedEnhancedPerks(t *testing.T) {
+	useEnhancedPlug(t)
+	weapons := []WeaponDefinition{{
+		WeaponName:   "Anonymous Autumn",
+		DesiredPerks: []string{"Repulsor Force", "Desperation"},
+		Bucket:       "Test",
+		Rank:         1,
+	}}
+
+	tests := []struct {
+		name    string
+		profile string
+		want    []string
+	}{
+		{
+			name: "enhanced perk inserted",
+			profile: `{"Response": {
+				"profileInventory": {"data": {"items": [{"itemHash": 1051949956, "itemInstanceId": "1"}]}},
+				"itemComponents": {"sockets": {"data": {"1": {"sockets": [{"plugHash": 1210807262}, {"plugHash": 525593296}]}}}}
+			}}`,
+			want: []string{"Desperation"},
+		},
+		{
+			name: "enhanced perk only selectable",
+			profile: `{"Response": {
+				"profileInventory": {"data": {"items": [{"itemHash": 1051949956, "itemInstanceId": "1"}]}},
+				"itemComponents": {
+					"sockets": {"data": {"1": {"sockets": [{"plugHash": 1210807262}, {"plugHash": 0}]}}},
+					"reusablePlugs": {"data": {"1": {"plugs": {"1": [{"plugItemHash": 525593296, "canInsert": true, "enabled": true}]}}}}
+				}
+			}}`,
+			want: nil,
+		},
+		{
+			name: "enhanced perk inserted but not yet unlocked",
+			profile: `{"Response": {
+				"profileInventory": {"data": {"items": [{"itemHash": 1051949956, "itemInstanceId": "1"}]}},
+				"itemComponents": {
+					"sockets": {"data": {"1": {"sockets": [{"plugHash": 1210807262}, {"plugHash": 525593296}]}}},
+					"plugObjectives": {"data": {"1": {"objectivesPerPlug": {"525593296": [{"complete": false}]}}}}
+				}
+			}}`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation, err := evaluateInventory(testProfile(t, tt.profile), weapons)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roll, ok := evaluation.BestRolls["Anonymous Autumn"]
+			if !ok {
+				t.Fatal("the roll did not qualify")
+			}
+			if !reflect.DeepEqual(roll.EnhancedPerks, tt.want) {
+				t.Errorf("EnhancedPerks = %v, want %v", roll.EnhancedPerks, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateEvaluatedInventoryKeepsBonusOutOfPotentialPoints(t *testing.T) {
+	bucket := constants.BucketPoints[0].BucketName
+	owned := WeaponDefinition{WeaponName: "Owned", Bucket: bucket, Rank: 2}
+	missing := WeaponDefinition{WeaponName: "Missing", Bucket: bucket, Rank: 1}
+	weapons := []WeaponDefinition{owned, missing}
+
+	rate := func(roll rollInfo) ResponseData {
+		return rateEvaluatedInventory(ProfileData{}, weapons, inventoryEvaluation{
+			OwnedWeaponsPerBucket: map[string][]WeaponDefinition{bucket: {owned}},
+			BestRolls:             map[string]rollInfo{owned.WeaponName: roll},
+		})
+	}
+	plain := rate(rollInfo{})
+	boosted := rate(rollInfo{Crafted: true, EnhancedPerks: []string{"Enhanced A"}})
+
+	bonus := CraftedWeaponBonus + EnhancedPerkBonus
+	if got := boosted.InventoryRating.TotalPoints - plain.InventoryRating.TotalPoints; got != bonus {
+		t.Errorf("total points grew by %v, want %v", got, bonus)
+	}
+	if got := boosted.BucketDetails[0].CurrentPoints - plain.BucketDetails[0].CurrentPoints; got != bonus {
+		t.Errorf("bucket points grew by %v, want %v", got, bonus)
+	}
+	if got := boosted.WeaponDetails[1].Points; got != plain.WeaponDetails[1].Points {
+		t.Errorf("potential points of the missing weapon changed from %v to %v", plain.WeaponDetails[1].Points, got)
+	}
+	if boosted.NextImportantGun.Points != plain.NextImportantGun.Points {
+		t.Errorf("next gun points changed from %v to %v", plain.NextImportantGun.Points, boosted.NextImportantGun.Points)
+	}
+}
+
+func TestEvaluateInventoryMatchesSelectablePerks(t *testing.T) {
+	weapons := []WeaponDefinition{{
+		WeaponName:   "Anonymous Autumn",
+		DesiredPerks: []string{"Repulsor Force", "Desperation"},
+		Bucket:       "Test",
+		Rank:         1,
+	}}
+	// Repulsor Force is inserted in the first column; the second column only
+	// offers Desperation through the reusable plugs
+	profileWithPlugs := func(plugs string) string {
+		return `{"Response": {
+			"profileInventory": {"data": {"items": [{"itemHash": 1051949956, "itemInstanceId": "1"}]}},
+			"itemComponents": {
+				"sockets": {"data": {"1": {"sockets": [{"plugHash": 1210807262}, {"plugHash": 0}]}}},
+				"reusablePlugs": {"data": {"1": {"plugs": ` + plugs + `}}}
+			}
+		}}`
+	}
+
+	tests := []struct {
+		name          string
+		plugs         string
+		wantMatching  int
+		wantQualifies bool
+	}{
+		{
+			name:          "desired perk only in the reusable plugs",
+			plugs:         `{"1": [{"plugItemHash": 525593296, "canInsert": true, "enabled": true}]}`,
+			wantMatching:  2,
+			wantQualifies: true,
+		},
+		{
+			name:          "column offering two desired perks counts once",
+			plugs:         `{"0": [{"plugItemHash": 525593296, "canInsert": true, "enabled": true}]}`,
+			wantMatching:  1,
+			wantQualifies: false,
+		},
+		{
+			name:          "plug that cannot be inserted is ignored",
+			plugs:         `{"1": [{"plugItemHash": 525593296, "canInsert": false, "enabled": true}]}`,
+			wantMatching:  1,
+			wantQualifies: false,
+		},
+		{
+			name:          "disabled plug is ignored",
+			plugs:         `{"1": [{"plugItemHash": 525593296, "canInsert": true, "enabled": false}]}`,
+			wantMatching:  1,
+			wantQualifies: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluation, err := evaluateInventory(testProfile(t, profileWithPlugs(tt.plugs)), weapons)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(evaluation.Instances) != 1 {
+				t.Fatalf("got %d instances, want 1", len(evaluation.Instances))
+			}
+			rated := evaluation.Instances[0]
+			if rated.Roll.MatchingPerks != tt.wantMatching || rated.Qualifies != tt.wantQualifies {
+				t.Errorf("MatchingPerks = %d, Qualifies = %v, want %d, %v", rated.Roll.MatchingPerks, rated.Qualifies, tt.wantMatching, tt.wantQualifies)
+			}
+			if _, best := evaluation.BestRolls["Anonymous Autumn"]; best != tt.wantQualifies {
+				t.Errorf("best roll recorded = %v, want %v", best, tt.wantQualifies)
+			}
+		})
+	}
+}
+
+func TestRollInfoBetterThan(t *testing.T) {
+	tests := []struct {
+		name        string
+		roll, other rollInfo
+		want        bool
+	}{
+		{
+			name:  "more matching perks win",
+			roll:  rollInfo{MatchingPerks: 3},
+			other: rollInfo{MatchingPerks: 2, Crafted: true, PreferredMasterwork: true},
+			want:  true,
+		},
+		{
+			name:  "minimum stats beat the bonus",
+			roll:  rollInfo{MatchingPerks: 2, Instance: InstanceDetail{MeetsMinStats: true}},
+			other: rollInfo{MatchingPerks: 2, Crafted: true, EnhancedPerks: []string{"Enhanced A"}},
+			want:  true,
+		},
+		{
+			name:  "preferred masterwork beats the bonus",
+			roll:  rollInfo{MatchingPerks: 2, Crafted: true},
+			other: rollInfo{MatchingPerks: 2, PreferredMasterwork: true},
+			want:  false,
+		},
+		{
+			name:  "bonus beats power",
+			roll:  rollInfo{MatchingPerks: 2, Crafted: true, Instance: InstanceDetail{Power: 1800}},
+			other: rollInfo{MatchingPerks: 2, Instance: InstanceDetail{Power: 2000}},
+			want:  true,
+		},
+		{
+			name:  "power is the final tiebreak",
+			roll:  rollInfo{MatchingPerks: 2, Instance: InstanceDetail{Power: 2000}},
+			other: rollInfo{MatchingPerks: 2, Instance: InstanceDetail{Power: 1990}},
+			want:  true,
+		},
+		{
+			name:  "equal rolls are not better",
+			roll:  rollInfo{MatchingPerks: 2, Instance: InstanceDetail{Power: 2000}},
+			other: rollInfo{MatchingPerks: 2, Instance: InstanceDetail{Power: 2000}},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.roll.betterThan(tt.other); got != tt.want {
+				t.Errorf("betterThan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Plug hash of the handling masterwork in useMasterworkManifest
+const testHandlingMasterwork = 150943607
+
+// useMasterworkManifest swaps in a manifest with a handling masterwork plug
+// and the handling and impact stat definitions.
+func useMasterworkManifest(t *testing.T) {
+	t.Helper()
+	previousItems, previousStats := items, stats
+	t.Cleanup(func() { items, stats = previousItems, previousStats })
+
+	var plug ItemDefinition
+	if err := json.Unmarshal([]byte(`{
+		"displayProperties": {"name": "Masterworked: Handling"},
+		"plug": {"plugCategoryIdentifier": "v400.weapon.mod_masterworks.stat.handling"}
+	}`), &plug); err != nil {
+		t.Fatal(err)
+	}
+	items = map[string]ItemDefinition{"150943607": plug}
+
+	stats = map[string]StatDefinition{}
+	for hash, name := range map[string]string{"943549884": "Handling", "4043523819": "Impact"} {
+		var stat StatDefinition
+		stat.DisplayProperties.Name = name
+		stats[hash] = stat
+	}
+}
+
+func testSockets(plugHashes ...int64) ItemSockets {
+	var sockets ItemSockets
+	for _, plugHash := range plugHashes {
+		sockets.Sockets = append(sockets.Sockets, Socket{PlugHash: plugHash})
+	}
+	return sockets
+}
+
+func TestMasterworkStat(t *testing.T) {
+	useMasterworkManifest(t)
+
+	tests := []struct {
+		name    string
+		sockets ItemSockets
+		want    string
+	}{
+		{name: "no sockets", want: ""},
+		{name: "unknown plugs", sockets: testSockets(1210807262, 525593296), want: ""},
+		{name: "masterwork plug", sockets: testSockets(1210807262, testHandlingMasterwork), want: "Handling"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := masterworkStat(tt.sockets); got != tt.want {
+				t.Errorf("masterworkStat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeInstanceMapsStatNames(t *testing.T) {
+	useMasterworkManifest(t)
+	profileData := testProfile(t, `{"Response": {"itemComponents": {"stats": {"data": {"1": {"stats": {
+		"943549884": {"statHash": 943549884, "value": 70},
+		"4043523819": {"statHash": 4043523819, "value": 84},
+		"1": {"statHash": 1, "value": 5}
+	}}}}}}}`)
+	item := InventoryItem{ItemInstanceID: "1"}
+	sockets := testSockets(testHandlingMasterwork)
+
+	tests := []struct {
+		name          string
+		weapon        WeaponDefinition
+		wantMinStats  bool
+		wantPreferred bool
+	}{
+		{name: "no stat rules", weapon: WeaponDefinition{}, wantMinStats: true},
+		{name: "minimum met", weapon: WeaponDefinition{MinStats: map[string]int{"Handling": 70}}, wantMinStats: true},
+		{name: "minimum missed", weapon: WeaponDefinition{MinStats: map[string]int{"Impact": 85}}, wantMinStats: false},
+		{name: "preferred masterwork", weapon: WeaponDefinition{PreferredMasterworks: []string{"handling"}}, wantMinStats: true, wantPreferred: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, preferred := describeInstance(profileData, item, sockets, tt.weapon)
+			wantStats := map[string]int{"Handling": 70, "Impact": 84}
+			if !reflect.DeepEqual(detail.Stats, wantStats) {
+				t.Errorf("Stats = %v, want %v", detail.Stats, wantStats)
+			}
+			if detail.Masterwork != "Handling" {
+				t.Errorf("Masterwork = %q, want %q", detail.Masterwork, "Handling")
+			}
+			if detail.MeetsMinStats != tt.wantMinStats || preferred != tt.wantPreferred {
+				t.Errorf("MeetsMinStats = %v, preferred = %v, want %v, %v", detail.MeetsMinStats, preferred, tt.wantMinStats, tt.wantPreferred)
+			}
+		})
+	}
+}
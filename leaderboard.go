@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+)
+
+const weeklyGainWindow = 7 * 24 * time.Hour
+
+// isLeaderboardMember reports whether the user has opted in to leaderboards.
+func (api *apiConfig) isLeaderboardMember(ctx context.Context, userID int64) (bool, error) {
+	_, err := api.Store.GetLeaderboardEntry(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// saveLeaderboardRating stores the user's latest rating and per-bucket
+// completion. Saving the entry is what opts the user in.
+func (api *apiConfig) saveLeaderboardRating(ctx context.Context, userID int64, rating ResponseData) error {
+	buckets := make([]datastore.BucketScore, 0, len(rating.BucketDetails))
+	for _, bucket := range rating.BucketDetails {
+		completion := 0.0
+		if bucket.MaxPoints > 0 {
+			completion = bucket.CurrentPoints / bucket.MaxPoints
+		}
+		buckets = append(buckets, datastore.BucketScore{
+			Name:          bucket.Name,
+			CurrentPoints: bucket.CurrentPoints,
+			MaxPoints:     bucket.MaxPoints,
+			Completion:    completion,
+		})
+	}
+
+	return api.Store.SaveLeaderboardRating(ctx, datastore.LeaderboardRating{
+		UserID:      userID,
+		DisplayName: rating.Username,
+		TotalPoints: rating.InventoryRating.TotalPoints,
+		MaxPoints:   rating.InventoryRating.MaxPossiblePoints,
+		Buckets:     buckets,
+		RatedAt:     time.Now().UTC(),
+	})
+}
+
+// removeLeaderboardRating opts the user out, dropping their stored ratings.
+func (api *apiConfig) removeLeaderboardRating(ctx context.Context, userID int64) error {
+	tx, err := api.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := api.DB.WithTx(tx)
+
+	if err := queries.DeleteLeaderboardBucketScores(ctx, userID); err != nil {
+		return err
+	}
+	if err := queries.DeleteRatingSnapshots(ctx, userID); err != nil {
+		return err
+	}
+	if err := queries.DeleteLeaderboardEntry(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// weeklyGain compares the total against the rating at the start of the week:
+// the last snapshot taken before it, or the first one within the week for
+// players who joined since. The snapshot saved for the current rating is never
+// the baseline unless it is the player's only one.
+func (api *apiConfig) weeklyGain(ctx context.Context, userID int64, totalPoints float64, weekStart time.Time) (float64, error) {
+	baseline, err := api.Store.GetLastRatingSnapshotBefore(ctx, database.GetLastRatingSnapshotBeforeParams{
+		UserID:    userID,
+		CreatedAt: weekStart,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		baseline, err = api.Store.GetFirstRatingSnapshotSince(ctx, database.GetFirstRatingSnapshotSinceParams{
+			UserID:    userID,
+			CreatedAt: weekStart,
+		})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return totalPoints - baseline.TotalPoints, nil
+}
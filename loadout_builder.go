@@ -0,0 +1,193 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+// Inventory bucket type hashes for the three weapon slots
+const (
+	kineticSlotBucketHash = 1498876634
+	energySlotBucketHash  = 2465295065
+	powerSlotBucketHash   = 953998645
+)
+
+var weaponSlotNames = map[int64]string{
+	kineticSlotBucketHash: "Kinetic",
+	energySlotBucketHash:  "Energy",
+	powerSlotBucketHash:   "Power",
+}
+
+// Candidates kept per slot before combining; keeps the search small
+const loadoutCandidatesPerSlot = 6
+
+// Number of ranked loadouts returned besides the best one
+const loadoutAlternatives = 5
+
+type LoadoutWeapon struct {
+	WeaponName  string  `json:"weaponName"`
+	Icon        string  `json:"icon"`
+	WeaponType  string  `json:"weaponType"`
+	Bucket      string  `json:"bucket"`
+	Slot        string  `json:"slot"`
+	Exotic      bool    `json:"exotic"`
+	InstanceID  string  `json:"instanceId"`
+	CharacterID string  `json:"characterId,omitempty"`
+	Location    string  `json:"location"`
+	Score       float64 `json:"score"`
+}
+
+type Loadout struct {
+	Kinetic *LoadoutWeapon `json:"kinetic"`
+	Energy  *LoadoutWeapon `json:"energy"`
+	Power   *LoadoutWeapon `json:"power"`
+	Score   float64        `json:"score"`
+}
+
+type LoadoutResponse struct {
+	Activity     string    `json:"activity"`
+	Description  string    `json:"description"`
+	Best         *Loadout  `json:"best"`
+	Alternatives []Loadout `json:"alternatives"`
+}
+
+// rankPoints returns the points the top weapon of a bucket earns for its rank.
+func rankPoints(rank int, bp constants.BucketPoint) float64 {
+	points := bp.MaxPoints - 0.2*float64(rank-1)*bp.MaxPoints
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// buildLoadouts picks one kinetic, one energy and one power weapon from the
+// user's best owned rolls, scoring each by its bucket's weight in the activity
+// and allowing at most one exotic per loadout.
+func buildLoadouts(profileData ProfileData, weapons []WeaponDefinition, activity constants.ActivityProfile) (LoadoutResponse, error) {
+	evaluation, err := evaluateInventory(profileData, weapons)
+	if err != nil {
+		return LoadoutResponse{}, err
+	}
+
+	candidates := make(map[int64][]LoadoutWeapon) // slot bucket hash -> candidates
+	for _, instance := range evaluation.Instances {
+		best, owned := evaluation.BestRolls[instance.Weapon.WeaponName]
+		if !owned || best.InstanceID != instance.Item.ItemInstanceID {
+			continue
+		}
+		weight, relevant := activity.BucketWeights[instance.Weapon.Bucket]
+		if !relevant || weight <= 0 {
+			continue
+		}
+		bpIndex := findBucketIndex(instance.Weapon.Bucket, constants.BucketPoints)
+		if bpIndex == -1 {
+			continue
+		}
+		itemDef, found := items[strconv.FormatInt(instance.Item.ItemHash, 10)]
+		if !found {
+			continue
+		}
+		slot := itemDef.Inventory.BucketTypeHash
+		if _, isWeaponSlot := weaponSlotNames[slot]; !isWeaponSlot {
+			continue
+		}
+
+		candidates[slot] = append(candidates[slot], LoadoutWeapon{
+			WeaponName:  instance.Weapon.WeaponName,
+			Icon:        bungieIconURL(itemDef.DisplayProperties.Icon),
+			WeaponType:  itemDef.ItemTypeDisplayName,
+			Bucket:      instance.Weapon.Bucket,
+			Slot:        weaponSlotNames[slot],
+			Exotic:      itemDef.Inventory.TierTypeName == "Exotic",
+			InstanceID:  instance.Item.ItemInstanceID,
+			CharacterID: instance.Item.CharacterID,
+			Location:    instance.Item.Location,
+			Score:       rankPoints(instance.Weapon.Rank, constants.BucketPoints[bpIndex])*weight + best.bonus(),
+		})
+	}
+
+	for slot := range candidates {
+		sortLoadoutWeapons(candidates[slot])
+		if len(candidates[slot]) > loadoutCandidatesPerSlot {
+			candidates[slot] = candidates[slot][:loadoutCandidatesPerSlot]
+		}
+	}
+
+	// An empty slot is allowed so users still get a partial loadout
+	options := func(slot int64) []*LoadoutWeapon {
+		slotOptions := []*LoadoutWeapon{}
+		for i := range candidates[slot] {
+			slotOptions = append(slotOptions, &candidates[slot][i])
+		}
+		if len(slotOptions) == 0 {
+			slotOptions = append(slotOptions, nil)
+		}
+		return slotOptions
+	}
+
+	loadouts := []Loadout{}
+	for _, kinetic := range options(kineticSlotBucketHash) {
+		for _, energy := range options(energySlotBucketHash) {
+			for _, power := range options(powerSlotBucketHash) {
+				loadout := Loadout{Kinetic: kinetic, Energy: energy, Power: power}
+				exotics := 0
+				for _, weapon := range []*LoadoutWeapon{kinetic, energy, power} {
+					if weapon == nil {
+						continue
+					}
+					if weapon.Exotic {
+						exotics++
+					}
+					loadout.Score += weapon.Score
+				}
+				if exotics > 1 {
+					continue // Only one exotic weapon can be equipped
+				}
+				loadouts = append(loadouts, loadout)
+			}
+		}
+	}
+
+	sort.SliceStable(loadouts, func(i, j int) bool {
+		return loadouts[i].Score > loadouts[j].Score
+	})
+
+	response := LoadoutResponse{
+		Activity:     activity.Name,
+		Description:  activity.Description,
+		Alternatives: []Loadout{},
+	}
+	if len(loadouts) > 0 && loadouts[0].Score > 0 {
+		response.Best = &loadouts[0]
+		for _, loadout := range loadouts[1:] {
+			if len(response.Alternatives) == loadoutAlternatives {
+				break
+			}
+			response.Alternatives = append(response.Alternatives, loadout)
+		}
+	}
+
+	return response, nil
+}
+
+// sortLoadoutWeapons orders slot candidates by score, then name for stability.
+func sortLoadoutWeapons(weapons []LoadoutWeapon) {
+	sort.Slice(weapons, func(i, j int) bool {
+		if weapons[i].Score != weapons[j].Score {
+			return weapons[i].Score > weapons[j].Score
+		}
+		return weapons[i].WeaponName < weapons[j].WeaponName
+	})
+}
+
+// activityProfileNames lists the known activity profiles for error messages.
+func activityProfileNames() string {
+	names := []string{}
+	for _, profile := range constants.ActivityProfiles {
+		names = append(names, profile.Name)
+	}
+	return strings.Join(names, ", ")
+}
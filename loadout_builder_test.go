@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+func TestBuildLoadouts(t *testing.T) {
+	orbs := constants.BucketPoints[0].BucketName
+	kineticRocket := constants.BucketPoints[1].BucketName
+	energyRocket := constants.BucketPoints[2].BucketName
+
+	desired := []string{"Repulsor Force", "Desperation"}
+	weapons := []WeaponDefinition{
+		{WeaponName: "Anonymous Autumn", DesiredPerks: desired, Bucket: orbs, Rank: 1},
+		{WeaponName: "The Slammer", DesiredPerks: desired, Bucket: kineticRocket, Rank: 1},
+		{WeaponName: "Parasite", DesiredPerks: desired, Bucket: kineticRocket, Rank: 2},
+		{WeaponName: "Scintillation", DesiredPerks: desired, Bucket: energyRocket, Rank: 2},
+	}
+
+	// One kinetic, two energy and one power weapon; the exotic energy weapon
+	// and the exotic power weapon cannot be used together
+	previous := items
+	t.Cleanup(func() { items = previous })
+	items = map[string]ItemDefinition{}
+	for hash, inventory := range map[int64]string{
+		1051949956: fmt.Sprintf(`{"bucketTypeHash": %d, "tierTypeName": "Legendary"}`, kineticSlotBucketHash),
+		2889501828: fmt.Sprintf(`{"bucketTypeHash": %d, "tierTypeName": "Exotic"}`, energySlotBucketHash),
+		2812324400: fmt.Sprintf(`{"bucketTypeHash": %d, "tierTypeName": "Legendary"}`, energySlotBucketHash),
+		2158681379: fmt.Sprintf(`{"bucketTypeHash": %d, "tierTypeName": "Exotic"}`, powerSlotBucketHash),
+	} {
+		var item ItemDefinition
+		if err := json.Unmarshal([]byte(`{"itemType": 3, "inventory": `+inventory+`}`), &item); err != nil {
+			t.Fatal(err)
+		}
+		items[fmt.Sprint(hash)] = item
+	}
+
+	bothPerks := []int64{testRepulsorForce, testDesperation}
+	profileData := buildProfile([]testItem{
+		{instanceID: "autumn", hash: 1051949956, perks: bothPerks},
+		{instanceID: "slammer", hash: 2889501828, perks: bothPerks},
+		{instanceID: "parasite", hash: 2812324400, perks: bothPerks},
+		{instanceID: "scintillation", hash: 2158681379, perks: bothPerks},
+		{instanceID: "unrolled", hash: 1051949956, perks: []int64{}}, // Only best rolls are used
+	})
+
+	names := func(loadout Loadout) []string {
+		slots := []string{}
+		for _, weapon := range []*LoadoutWeapon{loadout.Kinetic, loadout.Energy, loadout.Power} {
+			if weapon == nil {
+				slots = append(slots, "")
+				continue
+			}
+			slots = append(slots, weapon.InstanceID)
+		}
+		return slots
+	}
+
+	tests := []struct {
+		name             string
+		weights          map[string]float64
+		wantBest         []string // Instance IDs per slot, nil when no loadout is expected
+		wantScore        float64
+		wantAlternatives [][]string
+	}{
+		{
+			name:      "only one exotic per loadout",
+			weights:   map[string]float64{orbs: 1, kineticRocket: 1, energyRocket: 1},
+			wantBest:  []string{"autumn", "parasite", "scintillation"},
+			wantScore: 10 + 8 + 8,
+		},
+		{
+			name:             "activity weights rank the loadouts",
+			weights:          map[string]float64{orbs: 0.5, kineticRocket: 2},
+			wantBest:         []string{"autumn", "slammer", ""},
+			wantScore:        5 + 20,
+			wantAlternatives: [][]string{{"autumn", "parasite", ""}},
+		},
+		{
+			name:      "slots without relevant weapons stay empty",
+			weights:   map[string]float64{orbs: 1},
+			wantBest:  []string{"autumn", "", ""},
+			wantScore: 10,
+		},
+		{
+			name:    "no relevant weapons",
+			weights: map[string]float64{"Unknown": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activity := constants.ActivityProfile{Name: "test", BucketWeights: tt.weights}
+			response, err := buildLoadouts(profileData, weapons, activity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantBest == nil {
+				if response.Best != nil {
+					t.Errorf("Best = %v, want none", names(*response.Best))
+				}
+				return
+			}
+			if response.Best == nil {
+				t.Fatal("no best loadout")
+			}
+			if got := names(*response.Best); !reflect.DeepEqual(got, tt.wantBest) {
+				t.Errorf("Best = %v, want %v", got, tt.wantBest)
+			}
+			if response.Best.Score != tt.wantScore {
+				t.Errorf("Best score = %v, want %v", response.Best.Score, tt.wantScore)
+			}
+			var alternatives [][]string
+			for _, loadout := range response.Alternatives {
+				alternatives = append(alternatives, names(loadout))
+			}
+			if !reflect.DeepEqual(alternatives, tt.wantAlternatives) {
+				t.Errorf("Alternatives = %v, want %v", alternatives, tt.wantAlternatives)
+			}
+		})
+	}
+}
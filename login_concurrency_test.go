@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+)
+
+const simultaneousLogins = 16
+
+func newSQLiteStore(t *testing.T) datastore.Store {
+	t.Helper()
+	db, err := openDatabase("file:" + filepath.Join(t.TempDir(), "d2loot.db") + "?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := applyMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	return datastore.NewSQL(db)
+}
+
+// useSQLite points the API's store and SQL-only queries at a fresh migrated
+// SQLite database.
+func useSQLite(t *testing.T, api *apiConfig) {
+	t.Helper()
+	db, err := openDatabase("file:" + filepath.Join(t.TempDir(), "d2loot.db") + "?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := applyMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	api.Store = datastore.NewSQL(db)
+	api.DB = database.New(db)
+	api.DBConn = db
+}
+
+func TestSimultaneousCallbacksCreateOneUser(t *testing.T) {
+	stores := map[string]func(t *testing.T) datastore.Store{
+		"memory": func(t *testing.T) datastore.Store { return datastore.NewMemory() },
+		"sqlite": newSQLiteStore,
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			api, _, _ := newTestAPI(t)
+			api.Store = newStore(t)
+
+			recorders := make([]*httptest.ResponseRecorder, simultaneousLogins)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := range recorders {
+				recorders[i] = httptest.NewRecorder()
+				wg.Add(1)
+				go func(rec *httptest.ResponseRecorder) {
+					defer wg.Done()
+					<-start
+					api.handleCallback(rec, httptest.NewRequest(http.MethodGet, "/callback?code=auth-code", nil))
+				}(recorders[i])
+			}
+			close(start)
+			wg.Wait()
+
+			ctx := context.Background()
+			user, err := api.Store.GetUserByMembershipID(ctx, testMembershipID)
+			if err != nil {
+				t.Fatalf("user not stored: %v", err)
+			}
+
+			for i, rec := range recorders {
+				if rec.Code != http.StatusFound {
+					t.Fatalf("login %d: status = %d, body %q", i, rec.Code, rec.Body.String())
+				}
+				req := httptest.NewRequest(http.MethodGet, "/user-data", nil)
+				for _, cookie := range rec.Result().Cookies() {
+					req.AddCookie(cookie)
+				}
+				session, err := store.Get(req, "session-name")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if session.Values["userID"] != user.ID {
+					t.Errorf("login %d: session user = %v, want %d", i, session.Values["userID"], user.ID)
+				}
+			}
+
+			if _, err := api.Store.GetUser(ctx, user.ID+1); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("a second user was created, err = %v", err)
+			}
+			tokens, err := api.Store.GetAuthTokens(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("tokens not stored: %v", err)
+			}
+			if tokens.AccessToken != testAccessToken {
+				t.Errorf("access token = %q, want %q", tokens.AccessToken, testAccessToken)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -14,18 +15,24 @@ import (
 	"golang.org/x/oauth2"
 
 	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
 
 	_ "github.com/mattn/go-sqlite3"
 	_ "github.com/tursodatabase/libsql-client-go/libsql"
 )
 
 type apiConfig struct {
-	DB              *database.Queries
+	Store           datastore.Store   // Users, tokens and everything the dashboard reads or writes
+	DB              *database.Queries // SQL only: leaderboard listings, opting out and account deletion
+	DBConn          *sql.DB           // Underlying connection for transactions
+	ProfileCache    ProfileCache      // Recently fetched profiles of logged-in users
 	ManifestDB      *sql.DB
 	API_KEY         string
 	CLIENT_ID       string
 	CLIENT_SECRET   string
 	FRONTEND_DOMAIN string
+	BUNGIE_BASE_URL string // Optional stand-in for https://www.bungie.net
+	ACTIONS_DRY_RUN bool   // Validate item actions without sending them
 }
 
 var oauth2Config *oauth2.Config
@@ -49,12 +56,14 @@ func main() {
 	sessionKey := os.Getenv("SESSION_KEY")
 	apiKey := os.Getenv("API_KEY")
 	frontendDomain := os.Getenv("FRONTEND_DOMAIN")
+	bungieBaseURL := os.Getenv("BUNGIE_BASE_URL")
 
 	if clientID == "" || clientSecret == "" || redirectURL == "" || sessionKey == "" || apiKey == "" {
 		log.Fatal("Missing required environment variables")
 	}
 
-	// Initialize oauth2Config
+	// Initialize oauth2Config. Bungie grants the scopes enabled in the app
+	// registration, so none are requested here.
 	oauth2Config = &oauth2.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
@@ -71,21 +80,74 @@ func main() {
 		CLIENT_ID:       clientID,
 		CLIENT_SECRET:   clientSecret,
 		FRONTEND_DOMAIN: frontendDomain,
+		BUNGIE_BASE_URL: bungieBaseURL,
+	}
+
+	// Optional dry run for transfer and equip actions
+	if dryRun := os.Getenv("ACTIONS_DRY_RUN"); dryRun != "" {
+		value, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			log.Fatalf("Invalid ACTIONS_DRY_RUN: %v", err)
+		}
+		apiCfg.ACTIONS_DRY_RUN = value
+	}
+
+	// Optional scoring overrides for crafted and enhanced rolls
+	if bonus := os.Getenv("CRAFTED_WEAPON_BONUS"); bonus != "" {
+		value, err := strconv.ParseFloat(bonus, 64)
+		if err != nil {
+			log.Fatalf("Invalid CRAFTED_WEAPON_BONUS: %v", err)
+		}
+		CraftedWeaponBonus = value
+	}
+	if bonus := os.Getenv("ENHANCED_PERK_BONUS"); bonus != "" {
+		value, err := strconv.ParseFloat(bonus, 64)
+		if err != nil {
+			log.Fatalf("Invalid ENHANCED_PERK_BONUS: %v", err)
+		}
+		EnhancedPerkBonus = value
 	}
 
 	// Set up database connection if needed
 	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" && isDevelopment(os.Getenv("APP_ENV")) {
+		dbURL = defaultDevDatabaseURL
+		log.Printf("DATABASE_URL not set, using local SQLite database %s", dbURL)
+	}
 	if dbURL == "" {
 		log.Println("DATABASE_URL environment variable is not set")
 		log.Println("Running without CRUD endpoints")
 	} else {
-		db, err := sql.Open("libsql", dbURL)
+		db, err := openDatabase(dbURL)
 		if err != nil {
 			log.Fatal(err)
 		}
-		dbQueries := database.New(db)
-		apiCfg.DB = dbQueries
+		if err := db.Ping(); err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		sqlStore := datastore.NewSQL(db)
+		apiCfg.Store = sqlStore
+		apiCfg.DB = sqlStore.Queries
+		apiCfg.DBConn = db
 		log.Println("Connected to database!")
+
+		// Apply pending schema migrations unless they are run separately
+		if skip, _ := strconv.ParseBool(os.Getenv("SKIP_MIGRATIONS")); skip {
+			log.Println("SKIP_MIGRATIONS set, not applying migrations")
+		} else if err := applyMigrations(db); err != nil {
+			log.Fatalf("Migrations failed: %v", err)
+		}
+	}
+
+	// Cache logged-in users' profiles in memory, or in the database when
+	// several instances should share them
+	apiCfg.ProfileCache = newMemoryProfileCache()
+	if os.Getenv("PROFILE_CACHE") == "db" {
+		if apiCfg.DB == nil {
+			log.Println("PROFILE_CACHE=db needs a database, caching profiles in memory")
+		} else {
+			apiCfg.ProfileCache = &dbProfileCache{db: apiCfg.DB}
+		}
 	}
 
 	client := &http.Client{}
@@ -112,24 +174,53 @@ func main() {
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://www." + frontendDomain + "/"}, // Your frontend's origin
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 	router.Get("/", handleMain)
+	router.Get("/healthz", apiCfg.healthHandler)
 	router.Get("/login", handleLogin)
-	router.Get("/callback", apiCfg.handleCallback)
-	router.Get("/user-data", apiCfg.userDataHandler)
-	/*router.Options("/user-data", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "https://www.d2loot.com/")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.WriteHeader(http.StatusNoContent)
-	})*/
-	router.Post("/api/logout", apiCfg.logoutHandler)
+	router.Get("/api/public/rating", apiCfg.publicRatingByNameHandler)
+	router.Get("/api/public/rating/{membershipType}/{membershipId}", apiCfg.publicRatingHandler)
+	router.Get("/api/clan/{groupId}/coverage", apiCfg.clanCoverageHandler)
+	router.Post("/api/fireteam", apiCfg.fireteamHandler)
+	router.Get("/api/wishlist.txt", apiCfg.wishlistHandler)
+	router.Get("/api/manifest/items/{hash}", apiCfg.manifestItemHandler)
+	router.Get("/api/manifest/weapons", apiCfg.manifestWeaponSearchHandler)
+	router.Get("/api/manifest/weapons/{hash}/perks", apiCfg.manifestWeaponPerksHandler)
+
+	// Routes that read or write users return 503 when there is no database
+	router.Group(func(r chi.Router) {
+		r.Use(apiCfg.requireDatabase)
+		r.Get("/callback", apiCfg.handleCallback)
+		r.Get("/user-data", apiCfg.userDataHandler)
+		/*r.Options("/user-data", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "https://www.d2loot.com/")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.WriteHeader(http.StatusNoContent)
+		})*/
+		r.Post("/api/logout", apiCfg.logoutHandler)
+		r.Get("/api/vault-cleanup", apiCfg.vaultCleanupHandler)
+		r.Get("/api/loadout", apiCfg.loadoutHandler)
+		r.Get("/api/export", apiCfg.exportHandler)
+		r.Post("/api/items/transfer", apiCfg.transferItemHandler)
+		r.Post("/api/items/equip", apiCfg.equipItemHandler)
+		r.Get("/api/goals", apiCfg.listGoalsHandler)
+		r.Post("/api/goals", apiCfg.createGoalHandler)
+		r.Delete("/api/goals/{goalId}", apiCfg.deleteGoalHandler)
+		r.Get("/api/account/export", apiCfg.accountExportHandler)
+		r.Delete("/api/account", apiCfg.accountDeleteHandler)
+		r.Get("/api/preferences", apiCfg.getPreferencesHandler)
+		r.Put("/api/preferences", apiCfg.updatePreferencesHandler)
+		r.Get("/api/leaderboard", apiCfg.leaderboardHandler)
+		r.Post("/api/leaderboard/opt-in", apiCfg.leaderboardOptInHandler)
+		r.Delete("/api/leaderboard/opt-in", apiCfg.leaderboardOptOutHandler)
+	})
 
 	srv := &http.Server{
 		Addr:              ":" + port,
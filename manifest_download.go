@@ -7,6 +7,10 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+
+	"github.com/adamararcane/d2-loot-backend/internal/generator"
+	"github.com/adamararcane/d2-loot-backend/internal/manifest"
 )
 
 // Struct to hold the manifest response with component paths
@@ -20,21 +24,114 @@ type ManifestResponse struct {
 
 // Structs for item definitions and plug definitions
 type ItemDefinition struct {
+	DisplayProperties struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	} `json:"displayProperties"`
+	Hash                int64   `json:"hash"`
+	ItemType            int     `json:"itemType"`
+	ItemSubType         int     `json:"itemSubType"`
+	ItemTypeDisplayName string  `json:"itemTypeDisplayName"`
+	DefaultDamageType   int     `json:"defaultDamageType"`
+	DefaultPerks        []int64 `json:"defaultPerks"`
+	Redacted            bool    `json:"redacted"`
+	EquippingBlock      struct {
+		AmmoType int `json:"ammoType"`
+	} `json:"equippingBlock"`
+	DefaultDamageTypeHash int64 `json:"defaultDamageTypeHash"`
+	CollectibleHash       int64 `json:"collectibleHash"`
+	Inventory             struct {
+		TierTypeName   string `json:"tierTypeName"`
+		BucketTypeHash int64  `json:"bucketTypeHash"`
+	} `json:"inventory"`
+	Quality struct {
+		CurrentVersion int `json:"currentVersion"`
+		Versions       []struct {
+			PowerCapHash int64 `json:"powerCapHash"`
+		} `json:"versions"`
+	} `json:"quality"`
+	Sockets struct {
+		SocketEntries []generator.SocketEntry `json:"socketEntries"`
+	} `json:"sockets"`
+	Plug *struct {
+		PlugCategoryIdentifier string `json:"plugCategoryIdentifier"`
+	} `json:"plug"`
+}
+
+// PlugSetDefinition shares its shape with the generator so perk columns can be
+// derived the same way at runtime and at generation time.
+type PlugSetDefinition = generator.PlugSetDefinition
+
+type CollectibleDefinition struct {
 	DisplayProperties struct {
 		Name string `json:"name"`
 	} `json:"displayProperties"`
-	ItemType     int     `json:"itemType"`
-	DefaultPerks []int64 `json:"defaultPerks"`
+	SourceString string `json:"sourceString"`
+	ItemHash     int64  `json:"itemHash"`
 }
 
-type PlugSetDefinition struct {
+type DamageTypeDefinition struct {
 	DisplayProperties struct {
 		Name string `json:"name"`
+		Icon string `json:"icon"`
+	} `json:"displayProperties"`
+	EnumValue int `json:"enumValue"`
+}
+
+type SocketTypeDefinition struct {
+	SocketCategoryHash int64 `json:"socketCategoryHash"`
+	PlugWhitelist      []struct {
+		CategoryIdentifier string `json:"categoryIdentifier"`
+	} `json:"plugWhitelist"`
+}
+
+type StatDefinition struct {
+	DisplayProperties struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
 	} `json:"displayProperties"`
 }
 
+type PowerCapDefinition struct {
+	PowerCap int `json:"powerCap"`
+}
+
+// Bungie enum values used by the weapon search filters
+var damageTypeNames = map[int]string{
+	1: "Kinetic",
+	2: "Arc",
+	3: "Solar",
+	4: "Void",
+	6: "Stasis",
+	7: "Strand",
+}
+
+var ammoTypeNames = map[int]string{
+	1: "Primary",
+	2: "Special",
+	3: "Heavy",
+}
+
+// Tables downloaded alongside the item and plug set definitions
+var supportingManifestTables = []string{
+	"DestinyCollectibleDefinition",
+	"DestinyDamageTypeDefinition",
+	"DestinySocketTypeDefinition",
+	"DestinyStatDefinition",
+	"DestinyPowerCapDefinition",
+}
+
+// Power caps at or above this value mean the item is not sunset
+const sunsetPowerCapThreshold = 999900
+
 var items map[string]ItemDefinition
 var perks map[string]PlugSetDefinition
+var collectibles map[string]CollectibleDefinition
+var damageTypes map[string]DamageTypeDefinition
+var socketTypes map[string]SocketTypeDefinition
+var stats map[string]StatDefinition
+var powerCaps map[string]PowerCapDefinition
 
 // ManageManifest handles downloading and parsing the manifest
 func ManageManifest(client *http.Client, apiKey string) error {
@@ -77,6 +174,18 @@ func ManageManifest(client *http.Client, apiKey string) error {
 		return fmt.Errorf("failed to download plug manifest content: %w", err)
 	}
 
+	// Download the smaller supporting tables
+	for _, table := range supportingManifestTables {
+		tablePath, ok := manifestMetadata.Response.JsonWorldComponentContentPaths.English[table]
+		if !ok {
+			return fmt.Errorf("%s URL not found in the manifest metadata", table)
+		}
+		err = downloadManifestContent(client, "https://www.bungie.net"+tablePath, table+".json")
+		if err != nil {
+			return fmt.Errorf("failed to download %s content: %w", table, err)
+		}
+	}
+
 	// Step 4: Load and parse the JSON files
 	items, err = loadItemManifestContent(outputItemFile)
 	if err != nil {
@@ -88,6 +197,31 @@ func ManageManifest(client *http.Client, apiKey string) error {
 		return fmt.Errorf("failed to load plug manifest content: %w", err)
 	}
 
+	collectibles, err = loadManifestTable[CollectibleDefinition]("DestinyCollectibleDefinition.json")
+	if err != nil {
+		return fmt.Errorf("failed to load collectible manifest content: %w", err)
+	}
+
+	damageTypes, err = loadManifestTable[DamageTypeDefinition]("DestinyDamageTypeDefinition.json")
+	if err != nil {
+		return fmt.Errorf("failed to load damage type manifest content: %w", err)
+	}
+
+	socketTypes, err = loadManifestTable[SocketTypeDefinition]("DestinySocketTypeDefinition.json")
+	if err != nil {
+		return fmt.Errorf("failed to load socket type manifest content: %w", err)
+	}
+
+	stats, err = loadManifestTable[StatDefinition]("DestinyStatDefinition.json")
+	if err != nil {
+		return fmt.Errorf("failed to load stat manifest content: %w", err)
+	}
+
+	powerCaps, err = loadManifestTable[PowerCapDefinition]("DestinyPowerCapDefinition.json")
+	if err != nil {
+		return fmt.Errorf("failed to load power cap manifest content: %w", err)
+	}
+
 	log.Println("Manifest loaded successfully.")
 	return nil
 }
@@ -113,6 +247,49 @@ func GetPerkName(perkHash string) (string, error) {
 	return "", fmt.Errorf("perk with hash %s not found", perkHash)
 }
 
+// GetDamageTypeName resolves an item's default damage type name, preferring the
+// damage type definition and falling back to the enum value
+func GetDamageTypeName(item ItemDefinition) string {
+	if damageType, found := damageTypes[strconv.FormatInt(item.DefaultDamageTypeHash, 10)]; found {
+		return damageType.DisplayProperties.Name
+	}
+	return damageTypeNames[item.DefaultDamageType]
+}
+
+// GetCollectibleSource returns the in-game source text of an item's collectible
+func GetCollectibleSource(item ItemDefinition) string {
+	if collectible, found := collectibles[strconv.FormatInt(item.CollectibleHash, 10)]; found {
+		return collectible.SourceString
+	}
+	return ""
+}
+
+// GetStatName retrieves a stat's name by its hash from the loaded manifest
+func GetStatName(statHash int64) string {
+	if stat, found := stats[strconv.FormatInt(statHash, 10)]; found {
+		return stat.DisplayProperties.Name
+	}
+	return ""
+}
+
+// IsSunset reports whether an item's current version has a power cap below the
+// current cap, meaning it can no longer be infused to current power
+func IsSunset(item ItemDefinition) bool {
+	versions := item.Quality.Versions
+	if len(versions) == 0 {
+		return false
+	}
+	current := item.Quality.CurrentVersion
+	if current < 0 || current >= len(versions) {
+		current = len(versions) - 1
+	}
+	powerCap, found := powerCaps[strconv.FormatInt(versions[current].PowerCapHash, 10)]
+	if !found {
+		return false
+	}
+	return powerCap.PowerCap < sunsetPowerCapThreshold
+}
+
 // Step 1: Download Manifest Metadata
 func downloadManifestMetadata(client *http.Client, apiKey string) (*ManifestResponse, error) {
 	url := "https://www.bungie.net/Platform/Destiny2/Manifest/"
@@ -181,36 +358,25 @@ func downloadManifestContent(client *http.Client, manifestURL, outputFile string
 
 // Step 4: Load Manifest Content and Parse JSON
 
-// Load Item Manifest Content and Parse JSON
+// Load Item Manifest Content and Parse JSON, keeping only weapons and the plugs
+// that can be socketed into them
 func loadItemManifestContent(filePath string) (map[string]ItemDefinition, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open manifest file: %w", err)
-	}
-	defer file.Close()
-
-	var items map[string]ItemDefinition
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&items); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest JSON: %w", err)
-	}
+	return manifest.LoadFile(filePath, isWeaponOrPlug)
+}
 
-	return items, nil
+// isWeaponOrPlug reports whether an item definition is needed at runtime.
+// Everything else is dropped while streaming, which also limits
+// /api/manifest/items/{hash} to weapons and plugs.
+func isWeaponOrPlug(item *ItemDefinition) bool {
+	return item.ItemType == 3 || item.Plug != nil
 }
 
 // Load Plug Manifest Content and Parse JSON
 func loadPlugManifestContent(filePath string) (map[string]PlugSetDefinition, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open manifest file: %w", err)
-	}
-	defer file.Close()
-
-	var plugs map[string]PlugSetDefinition
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&plugs); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest JSON: %w", err)
-	}
+	return manifest.LoadFile[PlugSetDefinition](filePath, nil)
+}
 
-	return plugs, nil
+// loadManifestTable parses one of the smaller manifest tables keyed by hash
+func loadManifestTable[T any](filePath string) (map[string]T, error) {
+	return manifest.LoadFile[T](filePath, nil)
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+
+	"github.com/adamararcane/d2-loot-backend/internal/migrate"
+	"github.com/adamararcane/d2-loot-backend/sql/schema"
+)
+
+// applyMigrations brings the database up to the embedded schema.
+func applyMigrations(db *sql.DB) error {
+	migrations, err := migrate.Load(schema.FS)
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.Up(context.Background(), db, migrations)
+	if err != nil {
+		return err
+	}
+	if len(applied) > 0 {
+		log.Printf("Applied migrations %v", applied)
+	}
+	return nil
+}
+
+// latestSchemaVersion is the newest embedded migration. The embedded files
+// never change at runtime, so they are parsed once.
+var latestSchemaVersion = sync.OnceValues(func() (int64, error) {
+	migrations, err := migrate.Load(schema.FS)
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+})
@@ -13,11 +13,13 @@ type ProfileData struct {
 			Data struct {
 				Items []InventoryItem `json:"items"`
 			} `json:"data"`
+			Privacy int `json:"privacy"`
 		} `json:"profileInventory"`
 		CharacterInventories struct {
 			Data map[string]struct {
 				Items []InventoryItem `json:"items"`
 			} `json:"data"`
+			Privacy int `json:"privacy"`
 		} `json:"characterInventories"`
 		CharacterEquipment struct {
 			Data map[string]struct {
@@ -28,7 +30,21 @@ type ProfileData struct {
 			Sockets struct {
 				Data map[string]ItemSockets `json:"data"`
 			} `json:"sockets"`
+			PlugObjectives struct {
+				Data map[string]ItemPlugObjectives `json:"data"`
+			} `json:"plugObjectives"`
+			ReusablePlugs struct {
+				Data map[string]ItemReusablePlugs `json:"data"`
+			} `json:"reusablePlugs"`
+			Instances struct {
+				Data map[string]ItemInstance `json:"data"`
+			} `json:"instances"`
+			Stats struct {
+				Data map[string]ItemStats `json:"data"`
+			} `json:"stats"`
 		} `json:"itemComponents"`
+		// When Bungie generated the response, which may predate our request
+		ResponseMintedTimestamp string `json:"responseMintedTimestamp"`
 	} `json:"Response"`
 	// ... other fields ...
 }
@@ -37,6 +53,50 @@ type InventoryItem struct {
 	ItemHash       int64  `json:"itemHash"`
 	ItemInstanceID string `json:"itemInstanceId"`
 	BucketHash     int64  `json:"bucketHash"`
+	State          int    `json:"state"`
+}
+
+// ItemPlugObjectives holds component 309 data: objective progress keyed by plug hash
+type ItemPlugObjectives struct {
+	ObjectivesPerPlug map[string][]Objective `json:"objectivesPerPlug"`
+}
+
+// ItemInstance holds component 300 data for a single item instance
+type ItemInstance struct {
+	DamageType     int   `json:"damageType"`
+	DamageTypeHash int64 `json:"damageTypeHash"`
+	PrimaryStat    struct {
+		StatHash int64 `json:"statHash"`
+		Value    int   `json:"value"`
+	} `json:"primaryStat"`
+	IsEquipped bool `json:"isEquipped"`
+	CanEquip   bool `json:"canEquip"`
+}
+
+// ItemStats holds component 304 data: instance stats keyed by stat hash
+type ItemStats struct {
+	Stats map[string]struct {
+		StatHash int64 `json:"statHash"`
+		Value    int   `json:"value"`
+	} `json:"stats"`
+}
+
+// ItemReusablePlugs holds component 310 data: selectable plugs keyed by socket index
+type ItemReusablePlugs struct {
+	Plugs map[string][]ReusablePlug `json:"plugs"`
+}
+
+type ReusablePlug struct {
+	PlugItemHash int64 `json:"plugItemHash"`
+	CanInsert    bool  `json:"canInsert"`
+	Enabled      bool  `json:"enabled"`
+}
+
+type Objective struct {
+	ObjectiveHash   int64 `json:"objectiveHash"`
+	Progress        int   `json:"progress"`
+	CompletionValue int   `json:"completionValue"`
+	Complete        bool  `json:"complete"`
 }
 
 type ItemSockets struct {
@@ -60,33 +120,56 @@ type Perk struct {
 }
 
 type WeaponDetail struct {
-	WeaponName       string   `json:"weaponName"`
-	Icon             string   `json:"icon"`
-	WeaponType       string   `json:"weaponType"`
-	WeaponBucket     string   `json:"weaponBucket"`
-	Points           float64  `json:"points"`
-	Perks            []Perk   `json:"perks"`
-	RecommendedPerks []string `json:"recommendedPerks"`
-	Obtained         bool     `json:"obtained"`
-	Description      string   `json:"description"`
-	Source           string   `json:"source"`
+	WeaponName       string          `json:"weaponName"`
+	Icon             string          `json:"icon"`
+	WeaponType       string          `json:"weaponType"`
+	WeaponBucket     string          `json:"weaponBucket"`
+	Points           float64         `json:"points"`
+	Perks            []Perk          `json:"perks"`
+	RecommendedPerks []string        `json:"recommendedPerks"`
+	Obtained         bool            `json:"obtained"`
+	Description      string          `json:"description"`
+	Source           string          `json:"source"`
+	DamageType       string          `json:"damageType"`
+	AmmoType         string          `json:"ammoType"`
+	Sunset           bool            `json:"sunset"`
+	Crafted          bool            `json:"crafted"`                // Best owned roll is a crafted copy
+	EnhancedPerks    []string        `json:"enhancedPerks"`          // Active enhanced perks on the best owned roll
+	BestInstance     *InstanceDetail `json:"bestInstance,omitempty"` // Best owned roll, if any
+}
+
+type InstanceDetail struct {
+	InstanceID     string         `json:"instanceId"`
+	Power          int            `json:"power"`
+	DamageType     string         `json:"damageType"`
+	Masterwork     string         `json:"masterwork"` // Stat boosted by the masterwork, if any
+	IsMasterworked bool           `json:"isMasterworked"`
+	Locked         bool           `json:"locked"`
+	Stats          map[string]int `json:"stats"`         // Stat name -> value
+	MeetsMinStats  bool           `json:"meetsMinStats"` // All catalog minimum stats are met
 }
 
 type NextImportantGun struct {
 	Name        string  `json:"name"`
 	Icon        string  `json:"icon"`
-	WeaponType  string  `json:"weaponType"`
+	WeaponType  string  `json:"weaponType"` // Bucket name, kept under this key for existing clients
+	TypeName    string  `json:"typeName"`   // Weapon type, e.g. "Hand Cannon"
+	Bucket      string  `json:"bucket"`
+	DamageType  string  `json:"damageType"`
+	AmmoType    string  `json:"ammoType"`
 	Description string  `json:"description"`
 	Source      string  `json:"source"`
 	Points      float64 `json:"points"`
 }
 
 type ResponseData struct {
-	Username         string           `json:"username"`         // User's display name
-	InventoryRating  InventoryRating  `json:"inventoryRating"`  // Overall inventory rating
-	NextImportantGun NextImportantGun `json:"nextImportantGun"` // Next weapon to acquire
-	WeaponDetails    []WeaponDetail   `json:"weaponDetails"`    // Detailed information about each weapon
-	BucketDetails    []BucketDetail   `json:"bucketDetails"`    // Detailed information about each bucket
+	Username         string           `json:"username"`                 // User's display name
+	InventoryRating  InventoryRating  `json:"inventoryRating"`          // Overall inventory rating
+	PersonalRating   *InventoryRating `json:"personalRating,omitempty"` // Rating weighted by the user's preferences
+	NextImportantGun NextImportantGun `json:"nextImportantGun"`         // Next weapon to acquire
+	WeaponDetails    []WeaponDetail   `json:"weaponDetails"`            // Detailed information about each weapon
+	BucketDetails    []BucketDetail   `json:"bucketDetails"`            // Detailed information about each bucket
+	Goals            []GoalProgress   `json:"goals,omitempty"`          // Progress on the user's pinned weapons
 }
 
 type InventoryRating struct {
@@ -103,4 +186,5 @@ type BucketDetail struct {
 	CurrentPoints    float64 `json:"currentPoints"`    // Current points based on obtained weapons
 	AdditionalCount  int     `json:"additionalCount"`  // Number of additional weapons obtained beyond the first
 	AdditionalPoints float64 `json:"additionalPoints"` // Points from additional weapons
+	Weight           float64 `json:"weight"`           // User's weight for the bucket, 0 when ignored
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+// profileCacheTTL is how long after Bungie minted a profile we keep serving it.
+const profileCacheTTL = 60 * time.Second
+
+// ProfileCache keeps recently fetched profiles of logged-in users so repeated
+// dashboard loads don't each call GetProfile. Entries may contain private
+// components, so they must only be served to the profile's owner.
+type ProfileCache interface {
+	Get(ctx context.Context, key string) (*ProfileData, bool, error)
+	Set(ctx context.Context, key string, profile *ProfileData, expiresAt time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+func profileCacheKey(membershipType int, membershipID string) string {
+	return fmt.Sprintf("%d:%s", membershipType, membershipID)
+}
+
+// profileCacheExpiry measures the TTL from Bungie's responseMintedTimestamp,
+// since Bungie may itself have served an older copy. It reports false when the
+// profile should not be cached: already stale, or missing inventory data
+// because a component came back private.
+func profileCacheExpiry(profile *ProfileData, now time.Time) (time.Time, bool) {
+	response := profile.Response
+	if response.ProfileInventory.Privacy == componentPrivacyPrivate && len(response.ProfileInventory.Data.Items) == 0 {
+		return time.Time{}, false
+	}
+	if response.CharacterInventories.Privacy == componentPrivacyPrivate && len(response.CharacterInventories.Data) == 0 {
+		return time.Time{}, false
+	}
+
+	minted, err := time.Parse(time.RFC3339, response.ResponseMintedTimestamp)
+	if err != nil || minted.After(now) {
+		minted = now
+	}
+	expiresAt := minted.Add(profileCacheTTL)
+	if !expiresAt.After(now) {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// memoryProfileCache is a per-process ProfileCache.
+type memoryProfileCache struct {
+	entries *ttlCache[*ProfileData]
+}
+
+func newMemoryProfileCache() *memoryProfileCache {
+	return &memoryProfileCache{entries: newTTLCache[*ProfileData](profileCacheTTL)}
+}
+
+func (c *memoryProfileCache) Get(ctx context.Context, key string) (*ProfileData, bool, error) {
+	profile, found := c.entries.Get(key)
+	return profile, found, nil
+}
+
+func (c *memoryProfileCache) Set(ctx context.Context, key string, profile *ProfileData, expiresAt time.Time) error {
+	c.entries.SetUntil(key, profile, expiresAt)
+	return nil
+}
+
+func (c *memoryProfileCache) Delete(ctx context.Context, key string) error {
+	c.entries.Delete(key)
+	return nil
+}
+
+// dbProfileCache stores profiles in the database so several server instances
+// share them.
+type dbProfileCache struct {
+	db *database.Queries
+}
+
+func (c *dbProfileCache) Get(ctx context.Context, key string) (*ProfileData, bool, error) {
+	row, err := c.db.GetCachedProfile(ctx, database.GetCachedProfileParams{
+		MembershipKey: key,
+		ExpiresAt:     time.Now().UTC(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var profile ProfileData
+	if err := json.Unmarshal([]byte(row.ProfileJson), &profile); err != nil {
+		return nil, false, err
+	}
+	return &profile, true, nil
+}
+
+func (c *dbProfileCache) Set(ctx context.Context, key string, profile *ProfileData, expiresAt time.Time) error {
+	encoded, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	if err := c.db.DeleteExpiredProfiles(ctx, time.Now().UTC()); err != nil {
+		return err
+	}
+	return c.db.UpsertCachedProfile(ctx, database.UpsertCachedProfileParams{
+		MembershipKey: key,
+		ProfileJson:   string(encoded),
+		ExpiresAt:     expiresAt.UTC(),
+	})
+}
+
+func (c *dbProfileCache) Delete(ctx context.Context, key string) error {
+	return c.db.DeleteCachedProfile(ctx, key)
+}
+
+// ownProfile fetches the logged-in user's profile, serving it from the
+// profile cache unless refresh is set. Cache failures fall back to Bungie.
+func (api *apiConfig) ownProfile(ctx context.Context, client *http.Client, user database.User, refresh bool) (*ProfileData, error) {
+	if api.ProfileCache == nil {
+		return api.getPlayerProfile(client, int(user.MembershipType), user.MembershipID)
+	}
+
+	key := profileCacheKey(int(user.MembershipType), user.MembershipID)
+	if !refresh {
+		profile, found, err := api.ProfileCache.Get(ctx, key)
+		if err != nil {
+			log.Printf("Failed to read cached profile %s: %v", key, err)
+		} else if found {
+			return profile, nil
+		}
+	}
+
+	profile, err := api.getPlayerProfile(client, int(user.MembershipType), user.MembershipID)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt, cacheable := profileCacheExpiry(profile, time.Now()); cacheable {
+		if err := api.ProfileCache.Set(ctx, key, profile, expiresAt); err != nil {
+			log.Printf("Failed to cache profile %s: %v", key, err)
+		}
+	}
+	return profile, nil
+}
+
+// forgetProfile drops a user's cached profile, e.g. on logout.
+func (api *apiConfig) forgetProfile(ctx context.Context, user database.User) {
+	if api.ProfileCache == nil {
+		return
+	}
+	key := profileCacheKey(int(user.MembershipType), user.MembershipID)
+	if err := api.ProfileCache.Delete(ctx, key); err != nil {
+		log.Printf("Failed to drop cached profile %s: %v", key, err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+const (
+	maxBucketWeight = 5.0
+	// preferredSourceBonus scales a missing weapon's value when it drops
+	// from one of the user's preferred sources.
+	preferredSourceBonus = 1.25
+)
+
+// RatingPreferences personalise the rating on top of constants.BucketPoints.
+type RatingPreferences struct {
+	BucketWeights    map[string]float64 `json:"bucketWeights"`    // Bucket name -> multiplier, 1 when unset
+	IgnoredBuckets   []string           `json:"ignoredBuckets"`   // Buckets left out of the personal score
+	PreferredSources []string           `json:"preferredSources"` // Sources to favour when recommending weapons
+}
+
+func (p RatingPreferences) isEmpty() bool {
+	return len(p.BucketWeights) == 0 && len(p.IgnoredBuckets) == 0 && len(p.PreferredSources) == 0
+}
+
+// weight returns the multiplier for a bucket, 0 if it is ignored.
+func (p RatingPreferences) weight(bucketName string) float64 {
+	for _, ignored := range p.IgnoredBuckets {
+		if ignored == bucketName {
+			return 0
+		}
+	}
+	if weight, ok := p.BucketWeights[bucketName]; ok {
+		return weight
+	}
+	return 1
+}
+
+func (p RatingPreferences) prefersSource(source string) bool {
+	for _, preferred := range p.PreferredSources {
+		if strings.Contains(strings.ToLower(source), strings.ToLower(preferred)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RatingPreferences) validate() error {
+	for bucketName, weight := range p.BucketWeights {
+		if findBucketIndex(bucketName, constants.BucketPoints) == -1 {
+			return fmt.Errorf("unknown bucket %q", bucketName)
+		}
+		if weight < 0 || weight > maxBucketWeight {
+			return fmt.Errorf("weight for %q must be between 0 and %.0f", bucketName, maxBucketWeight)
+		}
+	}
+	for _, bucketName := range p.IgnoredBuckets {
+		if findBucketIndex(bucketName, constants.BucketPoints) == -1 {
+			return fmt.Errorf("unknown bucket %q", bucketName)
+		}
+	}
+	for _, source := range p.PreferredSources {
+		if strings.TrimSpace(source) == "" {
+			return errors.New("preferred sources must not be empty")
+		}
+	}
+	return nil
+}
+
+// applyRatingPreferences adds the personal score and re-picks the next
+// weapon to chase. The global InventoryRating and bucket points are left
+// unweighted so they stay comparable between players.
+func applyRatingPreferences(response *ResponseData, prefs RatingPreferences) {
+	for i := range response.BucketDetails {
+		response.BucketDetails[i].Weight = prefs.weight(response.BucketDetails[i].Name)
+	}
+	if prefs.isEmpty() {
+		return
+	}
+
+	personal := InventoryRating{}
+	for _, bp := range constants.BucketPoints {
+		weight := prefs.weight(bp.BucketName)
+		personal.MaxPossiblePoints += (bp.MaxPoints + bp.AdditionalWeaponPts*5) * weight
+	}
+	for _, bucket := range response.BucketDetails {
+		personal.TotalPoints += bucket.CurrentPoints * bucket.Weight
+	}
+	response.PersonalRating = &personal
+
+	var next *WeaponDetail
+	bestScore := 0.0
+	for i, detail := range response.WeaponDetails {
+		if detail.Obtained {
+			continue
+		}
+		score := detail.Points * prefs.weight(detail.WeaponBucket)
+		if prefs.prefersSource(detail.Source) {
+			score *= preferredSourceBonus
+		}
+		if score > bestScore {
+			bestScore = score
+			next = &response.WeaponDetails[i]
+		}
+	}
+	if next == nil {
+		response.NextImportantGun = NextImportantGun{}
+		return
+	}
+	response.NextImportantGun = NextImportantGun{
+		Name:        next.WeaponName,
+		Icon:        next.Icon,
+		WeaponType:  next.WeaponBucket,
+		TypeName:    next.WeaponType,
+		Bucket:      next.WeaponBucket,
+		DamageType:  next.DamageType,
+		AmmoType:    next.AmmoType,
+		Description: next.Description,
+		Source:      next.Source,
+		Points:      bestScore,
+	}
+}
+
+// loadRatingPreferences returns the user's stored preferences, or empty ones.
+func (api *apiConfig) loadRatingPreferences(ctx context.Context, userID int64) (RatingPreferences, error) {
+	prefs := RatingPreferences{
+		BucketWeights:    map[string]float64{},
+		IgnoredBuckets:   []string{},
+		PreferredSources: []string{},
+	}
+	row, err := api.Store.GetUserPreferences(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	if err := json.Unmarshal([]byte(row.BucketWeights), &prefs.BucketWeights); err != nil {
+		return prefs, fmt.Errorf("decoding bucket weights: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.IgnoredBuckets), &prefs.IgnoredBuckets); err != nil {
+		return prefs, fmt.Errorf("decoding ignored buckets: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.PreferredSources), &prefs.PreferredSources); err != nil {
+		return prefs, fmt.Errorf("decoding preferred sources: %w", err)
+	}
+	return prefs, nil
+}
+
+func (api *apiConfig) saveRatingPreferences(ctx context.Context, userID int64, prefs RatingPreferences) error {
+	bucketWeights, err := json.Marshal(prefs.BucketWeights)
+	if err != nil {
+		return err
+	}
+	ignoredBuckets, err := json.Marshal(prefs.IgnoredBuckets)
+	if err != nil {
+		return err
+	}
+	preferredSources, err := json.Marshal(prefs.PreferredSources)
+	if err != nil {
+		return err
+	}
+	return api.Store.UpsertUserPreferences(ctx, database.UpsertUserPreferencesParams{
+		UserID:           userID,
+		BucketWeights:    string(bucketWeights),
+		IgnoredBuckets:   string(ignoredBuckets),
+		PreferredSources: string(preferredSources),
+		UpdatedAt:        time.Now().UTC(),
+	})
+}
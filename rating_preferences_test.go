@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+func TestApplyRatingPreferences(t *testing.T) {
+	first := constants.BucketPoints[0].BucketName
+	second := constants.BucketPoints[1].BucketName
+	newResponse := func() ResponseData {
+		return ResponseData{
+			BucketDetails: []BucketDetail{
+				{Name: first, CurrentPoints: 10},
+				{Name: second, CurrentPoints: 4},
+			},
+			WeaponDetails: []WeaponDetail{
+				{WeaponName: "Owned", WeaponBucket: first, Points: 50, Obtained: true},
+				{WeaponName: "Big", WeaponBucket: first, Points: 8, Source: "Vault of Glass"},
+				{WeaponName: "Small", WeaponBucket: second, Points: 7, Source: "Trials of Osiris"},
+			},
+			NextImportantGun: NextImportantGun{Name: "Big", Points: 8},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		prefs       RatingPreferences
+		wantWeights []float64
+		wantTotal   float64 // Personal total, -1 when no personal rating is expected
+		wantNext    string
+		wantPoints  float64
+	}{
+		{
+			name:        "empty preferences keep the global rating",
+			prefs:       RatingPreferences{},
+			wantWeights: []float64{1, 1},
+			wantTotal:   -1,
+			wantNext:    "Big",
+			wantPoints:  8,
+		},
+		{
+			name:        "ignored bucket drops out of the score and the next gun",
+			prefs:       RatingPreferences{IgnoredBuckets: []string{first}},
+			wantWeights: []float64{0, 1},
+			wantTotal:   4,
+			wantNext:    "Small",
+			wantPoints:  7,
+		},
+		{
+			name:        "bucket weight scales points",
+			prefs:       RatingPreferences{BucketWeights: map[string]float64{second: 2}},
+			wantWeights: []float64{1, 2},
+			wantTotal:   18,
+			wantNext:    "Small",
+			wantPoints:  14,
+		},
+		{
+			name:        "preferred source boosts a weapon",
+			prefs:       RatingPreferences{PreferredSources: []string{"trials"}},
+			wantWeights: []float64{1, 1},
+			wantTotal:   14,
+			wantNext:    "Small",
+			wantPoints:  7 * preferredSourceBonus,
+		},
+		{
+			name:        "no missing weapon left clears the next gun",
+			prefs:       RatingPreferences{IgnoredBuckets: []string{first, second}},
+			wantWeights: []float64{0, 0},
+			wantTotal:   0,
+			wantNext:    "",
+			wantPoints:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := newResponse()
+			applyRatingPreferences(&response, tt.prefs)
+
+			for i, bucket := range response.BucketDetails {
+				if bucket.Weight != tt.wantWeights[i] {
+					t.Errorf("bucket %s weight = %v, want %v", bucket.Name, bucket.Weight, tt.wantWeights[i])
+				}
+			}
+			if tt.wantTotal < 0 {
+				if response.PersonalRating != nil {
+					t.Errorf("PersonalRating = %+v, want nil", response.PersonalRating)
+				}
+			} else if response.PersonalRating == nil || response.PersonalRating.TotalPoints != tt.wantTotal {
+				t.Errorf("PersonalRating = %+v, want total %v", response.PersonalRating, tt.wantTotal)
+			}
+			if response.NextImportantGun.Name != tt.wantNext || response.NextImportantGun.Points != tt.wantPoints {
+				t.Errorf("next gun = %q (%v), want %q (%v)", response.NextImportantGun.Name, response.NextImportantGun.Points, tt.wantNext, tt.wantPoints)
+			}
+		})
+	}
+}
+
+func TestRatingPreferencesValidate(t *testing.T) {
+	bucket := constants.BucketPoints[0].BucketName
+	tests := []struct {
+		name    string
+		prefs   RatingPreferences
+		wantErr bool
+	}{
+		{name: "empty", prefs: RatingPreferences{}},
+		{name: "known bucket weight", prefs: RatingPreferences{BucketWeights: map[string]float64{bucket: maxBucketWeight}}},
+		{name: "unknown weighted bucket", prefs: RatingPreferences{BucketWeights: map[string]float64{"Nope": 1}}, wantErr: true},
+		{name: "weight too high", prefs: RatingPreferences{BucketWeights: map[string]float64{bucket: maxBucketWeight + 1}}, wantErr: true},
+		{name: "negative weight", prefs: RatingPreferences{BucketWeights: map[string]float64{bucket: -1}}, wantErr: true},
+		{name: "unknown ignored bucket", prefs: RatingPreferences{IgnoredBuckets: []string{"Nope"}}, wantErr: true},
+		{name: "blank source", prefs: RatingPreferences{PreferredSources: []string{" "}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.prefs.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
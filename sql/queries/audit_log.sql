@@ -0,0 +1,3 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (user_id, action, detail, created_at)
+VALUES (?, ?, ?, ?);
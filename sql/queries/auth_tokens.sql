@@ -15,4 +15,11 @@ WHERE user_id = ?;
 
 -- name: DeleteAuthTokens :exec
 DELETE FROM auth_tokens
-WHERE user_id = ?;
\ No newline at end of file
+WHERE user_id = ?;
+-- name: UpsertAuthTokens :exec
+INSERT INTO auth_tokens (user_id, access_token, refresh_token, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET access_token = excluded.access_token,
+    refresh_token = excluded.refresh_token,
+    expires_at = excluded.expires_at;
@@ -0,0 +1,170 @@
+-- name: UpsertLeaderboardEntry :exec
+INSERT INTO leaderboard_entries (user_id, display_name, total_points, max_points, updated_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET display_name = excluded.display_name,
+    total_points = excluded.total_points,
+    max_points = excluded.max_points,
+    updated_at = excluded.updated_at;
+
+-- name: GetLeaderboardEntry :one
+SELECT user_id, display_name, total_points, max_points, updated_at
+FROM leaderboard_entries
+WHERE user_id = ?;
+
+-- name: DeleteLeaderboardEntry :exec
+DELETE FROM leaderboard_entries
+WHERE user_id = ?;
+
+-- name: CountLeaderboardEntries :one
+SELECT COUNT(*)
+FROM leaderboard_entries;
+
+-- name: ListLeaderboardByTotal :many
+SELECT user_id, display_name, total_points, max_points, updated_at,
+    RANK() OVER (ORDER BY total_points DESC) AS rank
+FROM leaderboard_entries
+ORDER BY total_points DESC, user_id ASC
+LIMIT ? OFFSET ?;
+
+-- name: GetTotalPointsRank :one
+SELECT rank
+FROM (
+    SELECT user_id, RANK() OVER (ORDER BY total_points DESC) AS rank
+    FROM leaderboard_entries
+) ranked
+WHERE user_id = ?;
+
+-- name: UpsertLeaderboardBucketScore :exec
+INSERT INTO leaderboard_bucket_scores (user_id, bucket_name, current_points, max_points, completion)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id, bucket_name) DO UPDATE
+SET current_points = excluded.current_points,
+    max_points = excluded.max_points,
+    completion = excluded.completion;
+
+-- name: GetLeaderboardBucketScore :one
+SELECT user_id, bucket_name, current_points, max_points, completion
+FROM leaderboard_bucket_scores
+WHERE user_id = ? AND bucket_name = ?;
+
+-- name: DeleteLeaderboardBucketScores :exec
+DELETE FROM leaderboard_bucket_scores
+WHERE user_id = ?;
+
+-- name: CountBucketLeaderboard :one
+SELECT COUNT(*)
+FROM leaderboard_bucket_scores
+WHERE bucket_name = ?;
+
+-- name: ListBucketLeaderboard :many
+SELECT s.user_id, e.display_name, s.current_points, s.max_points, s.completion,
+    RANK() OVER (ORDER BY s.completion DESC, s.current_points DESC) AS rank
+FROM leaderboard_bucket_scores s
+JOIN leaderboard_entries e ON e.user_id = s.user_id
+WHERE s.bucket_name = ?
+ORDER BY s.completion DESC, s.current_points DESC, s.user_id ASC
+LIMIT ? OFFSET ?;
+
+-- name: GetBucketRank :one
+SELECT rank
+FROM (
+    SELECT s.user_id, RANK() OVER (ORDER BY s.completion DESC, s.current_points DESC) AS rank
+    FROM leaderboard_bucket_scores s
+    JOIN leaderboard_entries e ON e.user_id = s.user_id
+    WHERE s.bucket_name = ?
+) ranked
+WHERE user_id = ?;
+
+-- name: CreateRatingSnapshot :exec
+INSERT INTO rating_snapshots (user_id, total_points, created_at)
+VALUES (?, ?, ?);
+
+-- name: GetLatestRatingSnapshot :one
+SELECT id, user_id, total_points, created_at
+FROM rating_snapshots
+WHERE user_id = ?
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetLastRatingSnapshotBefore :one
+SELECT id, user_id, total_points, created_at
+FROM rating_snapshots
+WHERE user_id = ? AND created_at <= ?
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetFirstRatingSnapshotSince :one
+SELECT id, user_id, total_points, created_at
+FROM rating_snapshots
+WHERE user_id = ? AND created_at >= ?
+ORDER BY created_at ASC
+LIMIT 1;
+
+-- name: DeleteRatingSnapshots :exec
+DELETE FROM rating_snapshots
+WHERE user_id = ?;
+
+-- name: ListWeeklyGains :many
+WITH gains AS (
+    SELECT e.user_id, e.display_name,
+        -- Baseline: the last snapshot from before the week, else the first one in it
+        CAST(e.total_points - COALESCE((
+            SELECT s.total_points
+            FROM rating_snapshots s
+            WHERE s.user_id = e.user_id AND s.created_at <= ?
+            ORDER BY s.created_at DESC
+            LIMIT 1
+        ), (
+            SELECT s.total_points
+            FROM rating_snapshots s
+            WHERE s.user_id = e.user_id AND s.created_at >= ?
+            ORDER BY s.created_at ASC
+            LIMIT 1
+        ), e.total_points) AS REAL) AS weekly_gain
+    FROM leaderboard_entries e
+)
+SELECT user_id, display_name, weekly_gain,
+    RANK() OVER (ORDER BY weekly_gain DESC) AS rank
+FROM gains
+ORDER BY weekly_gain DESC, user_id ASC
+LIMIT ? OFFSET ?;
+
+-- name: GetWeeklyGainRank :one
+WITH gains AS (
+    SELECT e.user_id, e.display_name,
+        -- Baseline: the last snapshot from before the week, else the first one in it
+        CAST(e.total_points - COALESCE((
+            SELECT s.total_points
+            FROM rating_snapshots s
+            WHERE s.user_id = e.user_id AND s.created_at <= ?
+            ORDER BY s.created_at DESC
+            LIMIT 1
+        ), (
+            SELECT s.total_points
+            FROM rating_snapshots s
+            WHERE s.user_id = e.user_id AND s.created_at >= ?
+            ORDER BY s.created_at ASC
+            LIMIT 1
+        ), e.total_points) AS REAL) AS weekly_gain
+    FROM leaderboard_entries e
+),
+ranked AS (
+    SELECT user_id, RANK() OVER (ORDER BY weekly_gain DESC) AS rank
+    FROM gains
+)
+SELECT rank
+FROM ranked
+WHERE user_id = ?;
+
+-- name: ListLeaderboardBucketScores :many
+SELECT user_id, bucket_name, current_points, max_points, completion
+FROM leaderboard_bucket_scores
+WHERE user_id = ?
+ORDER BY bucket_name ASC;
+
+-- name: ListRatingSnapshots :many
+SELECT id, user_id, total_points, created_at
+FROM rating_snapshots
+WHERE user_id = ?
+ORDER BY created_at ASC;
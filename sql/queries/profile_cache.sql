@@ -0,0 +1,19 @@
+-- name: GetCachedProfile :one
+SELECT membership_key, profile_json, expires_at
+FROM profile_cache
+WHERE membership_key = ? AND expires_at > ?;
+
+-- name: UpsertCachedProfile :exec
+INSERT INTO profile_cache (membership_key, profile_json, expires_at)
+VALUES (?, ?, ?)
+ON CONFLICT (membership_key) DO UPDATE
+SET profile_json = excluded.profile_json,
+    expires_at = excluded.expires_at;
+
+-- name: DeleteCachedProfile :exec
+DELETE FROM profile_cache
+WHERE membership_key = ?;
+
+-- name: DeleteExpiredProfiles :exec
+DELETE FROM profile_cache
+WHERE expires_at <= ?;
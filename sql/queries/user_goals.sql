@@ -0,0 +1,26 @@
+-- name: CreateUserGoal :one
+-- Inserts nothing, so no row is returned, once the user has max_goals goals.
+-- Counting in the insert keeps simultaneous requests from passing the limit.
+INSERT INTO user_goals (user_id, weapon_name, target_perks)
+SELECT ?, ?, ?
+WHERE (SELECT COUNT(*) FROM user_goals WHERE user_id = ?) < CAST(sqlc.arg(max_goals) AS INTEGER)
+RETURNING id, user_id, weapon_name, target_perks, completed_at, created_at;
+
+-- name: ListUserGoals :many
+SELECT id, user_id, weapon_name, target_perks, completed_at, created_at
+FROM user_goals
+WHERE user_id = ?
+ORDER BY created_at ASC, id ASC;
+
+-- name: MarkUserGoalCompleted :exec
+UPDATE user_goals
+SET completed_at = ?
+WHERE id = ? AND completed_at IS NULL;
+
+-- name: DeleteUserGoal :execrows
+DELETE FROM user_goals
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteUserGoals :exec
+DELETE FROM user_goals
+WHERE user_id = ?;
@@ -0,0 +1,17 @@
+-- name: GetUserPreferences :one
+SELECT user_id, bucket_weights, ignored_buckets, preferred_sources, updated_at
+FROM user_preferences
+WHERE user_id = ?;
+
+-- name: UpsertUserPreferences :exec
+INSERT INTO user_preferences (user_id, bucket_weights, ignored_buckets, preferred_sources, updated_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET bucket_weights = excluded.bucket_weights,
+    ignored_buckets = excluded.ignored_buckets,
+    preferred_sources = excluded.preferred_sources,
+    updated_at = excluded.updated_at;
+
+-- name: DeleteUserPreferences :exec
+DELETE FROM user_preferences
+WHERE user_id = ?;
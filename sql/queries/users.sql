@@ -12,3 +12,14 @@ RETURNING id, membership_id, membership_type, created_at;
 SELECT id, membership_id, membership_type, created_at
 FROM users
 WHERE id = ?;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = ?;
+
+-- name: UpsertUser :one
+INSERT INTO users (membership_id, membership_type)
+VALUES (?, ?)
+ON CONFLICT (membership_id) DO UPDATE
+SET membership_type = excluded.membership_type
+RETURNING id, membership_id, membership_type, created_at;
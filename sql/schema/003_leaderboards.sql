@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS leaderboard_entries (
+    user_id INTEGER PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    total_points REAL NOT NULL,
+    max_points REAL NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS leaderboard_bucket_scores (
+    user_id INTEGER NOT NULL,
+    bucket_name TEXT NOT NULL,
+    current_points REAL NOT NULL,
+    max_points REAL NOT NULL,
+    completion REAL NOT NULL,
+    PRIMARY KEY (user_id, bucket_name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS rating_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    total_points REAL NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_entries_total ON leaderboard_entries(total_points);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_bucket_scores_bucket ON leaderboard_bucket_scores(bucket_name, completion);
+CREATE INDEX IF NOT EXISTS idx_rating_snapshots_user_created ON rating_snapshots(user_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_rating_snapshots_user_created;
+DROP INDEX IF EXISTS idx_leaderboard_bucket_scores_bucket;
+DROP INDEX IF EXISTS idx_leaderboard_entries_total;
+DROP TABLE IF EXISTS rating_snapshots;
+DROP TABLE IF EXISTS leaderboard_bucket_scores;
+DROP TABLE IF EXISTS leaderboard_entries;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    bucket_weights TEXT NOT NULL DEFAULT '{}',
+    ignored_buckets TEXT NOT NULL DEFAULT '[]',
+    preferred_sources TEXT NOT NULL DEFAULT '[]',
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_preferences;
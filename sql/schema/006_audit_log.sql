@@ -0,0 +1,15 @@
+-- +goose Up
+-- Audit entries outlive the users they describe, so user_id is not a foreign key
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON audit_log(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_log_user;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS profile_cache (
+    membership_key TEXT PRIMARY KEY,
+    profile_json TEXT NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS profile_cache;
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+)
+
+// maxUserGoals keeps the dashboard's goal list manageable.
+const maxUserGoals = 25
+
+// Goal is a catalog weapon the user has pinned, optionally with the perks
+// their roll should have.
+type Goal struct {
+	ID          int64      `json:"id"`
+	WeaponName  string     `json:"weaponName"`
+	TargetPerks []string   `json:"targetPerks"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+}
+
+// GoalProgress reports how close the user's inventory is to a goal.
+type GoalProgress struct {
+	Goal
+	Owned          bool     `json:"owned"`
+	BestInstanceID string   `json:"bestInstanceId,omitempty"` // Copy with the most target perks
+	MatchedPerks   []string `json:"matchedPerks"`
+	MissingPerks   []string `json:"missingPerks"`
+	Progress       float64  `json:"progress"` // 0-1
+	Completed      bool     `json:"completed"`
+	NewlyCompleted bool     `json:"newlyCompleted"` // Completed by an instance acquired since the last check
+}
+
+func toGoal(row database.UserGoal) (Goal, error) {
+	goal := Goal{ID: row.ID, WeaponName: row.WeaponName, TargetPerks: []string{}}
+	if err := json.Unmarshal([]byte(row.TargetPerks), &goal.TargetPerks); err != nil {
+		return Goal{}, fmt.Errorf("decoding target perks of goal %d: %w", row.ID, err)
+	}
+	if row.CompletedAt.Valid {
+		goal.CompletedAt = &row.CompletedAt.Time
+	}
+	if row.CreatedAt.Valid {
+		goal.CreatedAt = &row.CreatedAt.Time
+	}
+	return goal, nil
+}
+
+// validateGoal checks the weapon is in the catalog and the perks are known.
+func validateGoal(weapons []WeaponDefinition, weaponName string, targetPerks []string) error {
+	found := false
+	for _, weapon := range weapons {
+		if weapon.WeaponName == weaponName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("weapon %q is not in the catalog", weaponName)
+	}
+	for _, perk := range targetPerks {
+		if _, known := constants.PerkHashes[perk]; !known {
+			return fmt.Errorf("unknown perk %q", perk)
+		}
+	}
+	return nil
+}
+
+// evaluateGoal picks the owned copy of the goal weapon with the most target
+// perks. A goal without target perks is complete once the weapon is owned.
+func evaluateGoal(goal Goal, instances []ratedInstance) GoalProgress {
+	progress := GoalProgress{Goal: goal, MatchedPerks: []string{}, MissingPerks: goal.TargetPerks}
+
+	for _, instance := range instances {
+		if instance.Weapon.WeaponName != goal.WeaponName {
+			continue
+		}
+		available := make(map[string]struct{}, len(instance.Perks))
+		for _, perk := range instance.Perks {
+			available[perk] = struct{}{}
+		}
+		matched, missing := []string{}, []string{}
+		for _, perk := range goal.TargetPerks {
+			if _, ok := available[perk]; ok {
+				matched = append(matched, perk)
+			} else {
+				missing = append(missing, perk)
+			}
+		}
+		if !progress.Owned || len(matched) > len(progress.MatchedPerks) {
+			progress.BestInstanceID = instance.Item.ItemInstanceID
+			progress.MatchedPerks = matched
+			progress.MissingPerks = missing
+		}
+		progress.Owned = true
+	}
+
+	if progress.Owned {
+		progress.Progress = 1
+		if len(goal.TargetPerks) > 0 {
+			progress.Progress = float64(len(progress.MatchedPerks)) / float64(len(goal.TargetPerks))
+		}
+	}
+	progress.Completed = progress.Owned && len(progress.MissingPerks) == 0
+	return progress
+}
+
+// goalProgress evaluates the user's goals against their inventory, recording
+// the completion time the first time a goal is satisfied. Goals completed
+// earlier stay completed even if the weapon has since been dismantled.
+func (api *apiConfig) goalProgress(ctx context.Context, userID int64, evaluation inventoryEvaluation) ([]GoalProgress, error) {
+	rows, err := api.Store.ListUserGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []GoalProgress{}
+	now := time.Now().UTC()
+	for _, row := range rows {
+		goal, err := toGoal(row)
+		if err != nil {
+			return nil, err
+		}
+		progress := evaluateGoal(goal, evaluation.Instances)
+		if progress.Completed && goal.CompletedAt == nil {
+			if err := api.Store.MarkUserGoalCompleted(ctx, database.MarkUserGoalCompletedParams{
+				CompletedAt: sql.NullTime{Time: now, Valid: true},
+				ID:          goal.ID,
+			}); err != nil {
+				return nil, err
+			}
+			progress.CompletedAt = &now
+			progress.NewlyCompleted = true
+		}
+		if goal.CompletedAt != nil {
+			progress.Completed = true
+		}
+		results = append(results, progress)
+	}
+	return results, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adamararcane/d2-loot-backend/internal/database"
+	"github.com/adamararcane/d2-loot-backend/internal/datastore"
+)
+
+func goalInstance(instanceID, weaponName string, perks ...string) ratedInstance {
+	return ratedInstance{
+		Item:   ownedItem{InventoryItem: InventoryItem{ItemInstanceID: instanceID}},
+		Weapon: WeaponDefinition{WeaponName: weaponName},
+		Perks:  perks,
+	}
+}
+
+func TestEvaluateGoal(t *testing.T) {
+	instances := []ratedInstance{
+		goalInstance("1", "Fatebringer", "Explosive Payload"),
+		goalInstance("2", "Fatebringer", "Explosive Payload", "Firefly"),
+		goalInstance("3", "Anonymous Autumn", "Repulsor Force", "Desperation"),
+	}
+
+	tests := []struct {
+		name          string
+		goal          Goal
+		wantOwned     bool
+		wantInstance  string
+		wantMatched   []string
+		wantMissing   []string
+		wantProgress  float64
+		wantCompleted bool
+	}{
+		{
+			name:          "owned without target perks",
+			goal:          Goal{WeaponName: "Anonymous Autumn", TargetPerks: []string{}},
+			wantOwned:     true,
+			wantInstance:  "3",
+			wantMatched:   []string{},
+			wantMissing:   []string{},
+			wantProgress:  1,
+			wantCompleted: true,
+		},
+		{
+			name:          "copy with the most target perks wins",
+			goal:          Goal{WeaponName: "Fatebringer", TargetPerks: []string{"Explosive Payload", "Firefly"}},
+			wantOwned:     true,
+			wantInstance:  "2",
+			wantMatched:   []string{"Explosive Payload", "Firefly"},
+			wantMissing:   []string{},
+			wantProgress:  1,
+			wantCompleted: true,
+		},
+		{
+			name:         "partial roll",
+			goal:         Goal{WeaponName: "Fatebringer", TargetPerks: []string{"Explosive Payload", "Opening Shot"}},
+			wantOwned:    true,
+			wantInstance: "1",
+			wantMatched:  []string{"Explosive Payload"},
+			wantMissing:  []string{"Opening Shot"},
+			wantProgress: 0.5,
+		},
+		{
+			name:        "weapon not owned",
+			goal:        Goal{WeaponName: "Vision of Confluence", TargetPerks: []string{"Rampage"}},
+			wantMatched: []string{},
+			wantMissing: []string{"Rampage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := evaluateGoal(tt.goal, instances)
+			if progress.Owned != tt.wantOwned || progress.BestInstanceID != tt.wantInstance {
+				t.Errorf("owned = %v with %q, want %v with %q", progress.Owned, progress.BestInstanceID, tt.wantOwned, tt.wantInstance)
+			}
+			if !reflect.DeepEqual(progress.MatchedPerks, tt.wantMatched) || !reflect.DeepEqual(progress.MissingPerks, tt.wantMissing) {
+				t.Errorf("matched %v, missing %v; want %v, %v", progress.MatchedPerks, progress.MissingPerks, tt.wantMatched, tt.wantMissing)
+			}
+			if progress.Progress != tt.wantProgress {
+				t.Errorf("Progress = %v, want %v", progress.Progress, tt.wantProgress)
+			}
+			if progress.Completed != tt.wantCompleted {
+				t.Errorf("Completed = %v, want %v", progress.Completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestGoalProgressRecordsCompletionOnce(t *testing.T) {
+	memory := datastore.NewMemory()
+	api := &apiConfig{Store: memory}
+	ctx := context.Background()
+	if _, err := memory.CreateUserGoal(ctx, database.CreateUserGoalParams{UserID: 1, WeaponName: "Fatebringer", TargetPerks: `["Firefly"]`, MaxGoals: maxUserGoals}); err != nil {
+		t.Fatal(err)
+	}
+	owned := inventoryEvaluation{Instances: []ratedInstance{goalInstance("1", "Fatebringer", "Firefly")}}
+
+	results, err := api.goalProgress(ctx, 1, owned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].NewlyCompleted || results[0].CompletedAt == nil {
+		t.Fatalf("first check = %+v, want a newly completed goal", results)
+	}
+
+	// Dismantling the weapon keeps the goal completed without completing it again
+	results, err = api.goalProgress(ctx, 1, inventoryEvaluation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Completed || results[0].NewlyCompleted {
+		t.Errorf("second check = %+v, want completed but not newly", results[0])
+	}
+	if results[0].CompletedAt == nil || time.Since(*results[0].CompletedAt) > time.Minute {
+		t.Errorf("CompletedAt = %v, want the first completion time", results[0].CompletedAt)
+	}
+}
+
+func TestCreateUserGoalEnforcesLimitAndUniqueness(t *testing.T) {
+	stores := map[string]func(t *testing.T, api *apiConfig){
+		"memory": func(t *testing.T, api *apiConfig) {},
+		"sqlite": useSQLite,
+	}
+
+	for name, useStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			api, _, _ := newTestAPI(t)
+			useStore(t, api)
+			ctx := context.Background()
+			user, err := api.Store.SaveLogin(ctx, datastore.Login{MembershipID: "1", MembershipType: 3, ExpiresAt: time.Now()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			create := func(weaponName string) error {
+				_, err := api.Store.CreateUserGoal(ctx, database.CreateUserGoalParams{
+					UserID:      user.ID,
+					WeaponName:  weaponName,
+					TargetPerks: "[]",
+					MaxGoals:    3,
+				})
+				return err
+			}
+
+			// Simultaneous requests must not get past the limit
+			const attempts = 10
+			errs := make(chan error, attempts)
+			for i := 0; i < attempts; i++ {
+				go func(i int) { errs <- create(fmt.Sprintf("Weapon %d", i)) }(i)
+			}
+			created := 0
+			for i := 0; i < attempts; i++ {
+				err := <-errs
+				switch {
+				case err == nil:
+					created++
+				case !errors.Is(err, datastore.ErrGoalLimit):
+					t.Errorf("CreateUserGoal() error = %v, want %v", err, datastore.ErrGoalLimit)
+				}
+			}
+			if created != 3 {
+				t.Errorf("created %d goals, want 3", created)
+			}
+
+			goals, err := api.Store.ListUserGoals(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := api.Store.DeleteUserGoal(ctx, database.DeleteUserGoalParams{ID: goals[0].ID, UserID: user.ID}); err != nil {
+				t.Fatal(err)
+			}
+			if err := create(goals[1].WeaponName); !errors.Is(err, datastore.ErrDuplicate) {
+				t.Errorf("duplicate goal error = %v, want %v", err, datastore.ErrDuplicate)
+			}
+		})
+	}
+}
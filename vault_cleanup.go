@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/adamararcane/d2-loot-backend/cmd/constants"
+)
+
+// Bungie's vault holds at most this many items
+const vaultCapacity = 700
+
+const (
+	cleanupReasonDuplicate = "duplicate"
+	cleanupReasonNoBucket  = "no_bucket"
+)
+
+type CleanupCandidate struct {
+	InstanceID     string `json:"instanceId"`
+	ItemHash       int64  `json:"itemHash"`
+	Name           string `json:"name"`
+	Icon           string `json:"icon"`
+	WeaponType     string `json:"weaponType"`
+	Location       string `json:"location"`
+	Bucket         string `json:"bucket,omitempty"`
+	Reason         string `json:"reason"`                   // duplicate or no_bucket
+	Explanation    string `json:"explanation"`              // Human-readable reason
+	KeptInstanceID string `json:"keptInstanceId,omitempty"` // The better copy that is kept, for duplicates
+}
+
+type VaultCleanupResponse struct {
+	VaultCount    int                `json:"vaultCount"`
+	VaultCapacity int                `json:"vaultCapacity"`
+	Candidates    []CleanupCandidate `json:"candidates"`
+}
+
+// recommendVaultCleanup lists weapon instances that are safe to dismantle:
+// worse duplicates of catalog weapons and weapons that are in no bucket.
+// Locked and equipped items are never suggested.
+func recommendVaultCleanup(profileData ProfileData, weapons []WeaponDefinition) (VaultCleanupResponse, error) {
+	evaluation, err := evaluateInventory(profileData, weapons)
+	if err != nil {
+		return VaultCleanupResponse{}, err
+	}
+
+	// Pick the copy to keep per catalog weapon: the best qualifying roll, or the
+	// best roll overall when no copy has enough desired perks
+	kept := make(map[string]rollInfo)
+	for weaponName, roll := range evaluation.BestRolls {
+		kept[weaponName] = roll
+	}
+	evaluated := make(map[string]ratedInstance)
+	for _, instance := range evaluation.Instances {
+		evaluated[instance.Item.ItemInstanceID] = instance
+		if _, qualifies := evaluation.BestRolls[instance.Weapon.WeaponName]; qualifies {
+			continue
+		}
+		if current, seen := kept[instance.Weapon.WeaponName]; !seen || instance.Roll.betterThan(current) {
+			kept[instance.Weapon.WeaponName] = instance.Roll
+		}
+	}
+
+	catalogHashes := make(map[int64]struct{})
+	for _, weapon := range weapons {
+		for _, hash := range constants.WeaponHashes[weapon.WeaponName] {
+			catalogHashes[hash] = struct{}{}
+		}
+	}
+
+	response := VaultCleanupResponse{
+		VaultCapacity: vaultCapacity,
+		Candidates:    []CleanupCandidate{},
+	}
+
+	for _, item := range collectOwnedItems(profileData) {
+		if item.Location == "vault" {
+			response.VaultCount++
+		}
+
+		itemDef, found := items[strconv.FormatInt(item.ItemHash, 10)]
+		if !found || itemDef.ItemType != 3 || item.ItemInstanceID == "" {
+			continue // Only weapon instances are considered
+		}
+		if item.Location == "equipped" || item.State&itemStateLocked != 0 {
+			continue // Never touch equipped or locked weapons
+		}
+
+		candidate := CleanupCandidate{
+			InstanceID: item.ItemInstanceID,
+			ItemHash:   item.ItemHash,
+			Name:       itemDef.DisplayProperties.Name,
+			Icon:       bungieIconURL(itemDef.DisplayProperties.Icon),
+			WeaponType: itemDef.ItemTypeDisplayName,
+			Location:   item.Location,
+		}
+
+		if instance, isEvaluated := evaluated[item.ItemInstanceID]; isEvaluated {
+			keeper := kept[instance.Weapon.WeaponName]
+			if keeper.InstanceID == item.ItemInstanceID {
+				continue
+			}
+			candidate.Bucket = instance.Weapon.Bucket
+			candidate.Reason = cleanupReasonDuplicate
+			candidate.Explanation = "A better roll of " + instance.Weapon.WeaponName + " is already kept"
+			candidate.KeptInstanceID = keeper.InstanceID
+		} else if _, inCatalog := catalogHashes[item.ItemHash]; inCatalog {
+			continue // Catalog weapon without socket data; its roll is unknown
+		} else {
+			candidate.Reason = cleanupReasonNoBucket
+			candidate.Explanation = "Not part of any tier list bucket"
+		}
+
+		response.Candidates = append(response.Candidates, candidate)
+	}
+
+	// Duplicates first, then by name so the list is stable
+	sort.Slice(response.Candidates, func(i, j int) bool {
+		a, b := response.Candidates[i], response.Candidates[j]
+		if a.Reason != b.Reason {
+			return a.Reason == cleanupReasonDuplicate
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.InstanceID < b.InstanceID
+	})
+
+	return response, nil
+}
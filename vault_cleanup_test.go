@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const (
+	testCatalogWeaponHash = 1051949956 // Anonymous Autumn
+	testOtherWeaponHash   = 555
+	testArmorHash         = 777
+	testRepulsorForce     = 1210807262
+	testDesperation       = 525593296
+)
+
+// useCleanupItems swaps in a manifest with one catalog weapon, one weapon
+// outside the catalog and one armor piece.
+func useCleanupItems(t *testing.T) {
+	t.Helper()
+	previous := items
+	t.Cleanup(func() { items = previous })
+	items = map[string]ItemDefinition{}
+	for hash, raw := range map[string]string{
+		"1051949956": `{"displayProperties": {"name": "Anonymous Autumn"}, "itemType": 3}`,
+		"555":        `{"displayProperties": {"name": "Other Gun"}, "itemType": 3}`,
+		"777":        `{"displayProperties": {"name": "Helm"}, "itemType": 2}`,
+	} {
+		var item ItemDefinition
+		if err := json.Unmarshal([]byte(raw), &item); err != nil {
+			t.Fatal(err)
+		}
+		items[hash] = item
+	}
+}
+
+// testItem is an owned item for building profiles: perks are inserted plugs,
+// nil perks means no socket data.
+type testItem struct {
+	instanceID string
+	hash       int64
+	perks      []int64
+	locked     bool
+	equipped   bool
+	vault      bool
+}
+
+func buildProfile(owned []testItem) ProfileData {
+	var profileData ProfileData
+	profileData.Response.ItemComponents.Sockets.Data = map[string]ItemSockets{}
+	profileData.Response.CharacterEquipment.Data = map[string]struct {
+		Items []InventoryItem `json:"items"`
+	}{}
+	var equipped []InventoryItem
+	for _, owned := range owned {
+		item := InventoryItem{ItemHash: owned.hash, ItemInstanceID: owned.instanceID}
+		if owned.locked {
+			item.State |= itemStateLocked
+		}
+		if owned.vault {
+			item.BucketHash = vaultBucketHash
+		}
+		if owned.perks != nil {
+			sockets := ItemSockets{}
+			for _, perk := range owned.perks {
+				sockets.Sockets = append(sockets.Sockets, Socket{PlugHash: perk})
+			}
+			profileData.Response.ItemComponents.Sockets.Data[owned.instanceID] = sockets
+		}
+		if owned.equipped {
+			equipped = append(equipped, item)
+			continue
+		}
+		profileData.Response.ProfileInventory.Data.Items = append(profileData.Response.ProfileInventory.Data.Items, item)
+	}
+	profileData.Response.CharacterEquipment.Data["character"] = struct {
+		Items []InventoryItem `json:"items"`
+	}{Items: equipped}
+	return profileData
+}
+
+func TestRecommendVaultCleanup(t *testing.T) {
+	useCleanupItems(t)
+	weapons := []WeaponDefinition{{
+		WeaponName:   "Anonymous Autumn",
+		DesiredPerks: []string{"Repulsor Force", "Desperation"},
+		Bucket:       "Test",
+		Rank:         1,
+	}}
+	bothPerks := []int64{testRepulsorForce, testDesperation}
+	onePerk := []int64{testRepulsorForce}
+
+	tests := []struct {
+		name      string
+		owned     []testItem
+		want      []string // instanceID:reason, in response order
+		wantKept  map[string]string
+		wantVault int
+	}{
+		{
+			name: "worse duplicate of a qualifying roll",
+			owned: []testItem{
+				{instanceID: "1", hash: testCatalogWeaponHash, perks: bothPerks, vault: true},
+				{instanceID: "2", hash: testCatalogWeaponHash, perks: onePerk, vault: true},
+			},
+			want:      []string{"2:" + cleanupReasonDuplicate},
+			wantKept:  map[string]string{"2": "1"},
+			wantVault: 2,
+		},
+		{
+			name: "best roll is kept when no copy qualifies",
+			owned: []testItem{
+				{instanceID: "1", hash: testCatalogWeaponHash, perks: []int64{}},
+				{instanceID: "2", hash: testCatalogWeaponHash, perks: onePerk},
+			},
+			want:     []string{"1:" + cleanupReasonDuplicate},
+			wantKept: map[string]string{"1": "2"},
+		},
+		{
+			name: "locked and equipped weapons are never suggested",
+			owned: []testItem{
+				{instanceID: "1", hash: testCatalogWeaponHash, perks: bothPerks},
+				{instanceID: "2", hash: testCatalogWeaponHash, perks: onePerk, locked: true},
+				{instanceID: "3", hash: testCatalogWeaponHash, perks: onePerk, equipped: true},
+				{instanceID: "4", hash: testOtherWeaponHash, equipped: true},
+			},
+			want: []string{},
+		},
+		{
+			name: "weapons outside the catalog follow duplicates",
+			owned: []testItem{
+				{instanceID: "1", hash: testOtherWeaponHash, vault: true},
+				{instanceID: "2", hash: testArmorHash, vault: true},
+				{instanceID: "3", hash: testCatalogWeaponHash, perks: bothPerks},
+				{instanceID: "4", hash: testCatalogWeaponHash, perks: onePerk},
+			},
+			want:      []string{"4:" + cleanupReasonDuplicate, "1:" + cleanupReasonNoBucket},
+			wantKept:  map[string]string{"4": "3"},
+			wantVault: 2,
+		},
+		{
+			name: "catalog weapon without socket data is left alone",
+			owned: []testItem{
+				{instanceID: "1", hash: testCatalogWeaponHash, perks: bothPerks},
+				{instanceID: "2", hash: testCatalogWeaponHash},
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := recommendVaultCleanup(buildProfile(tt.owned), weapons)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, candidate := range response.Candidates {
+				got = append(got, candidate.InstanceID+":"+candidate.Reason)
+				if want := tt.wantKept[candidate.InstanceID]; candidate.KeptInstanceID != want {
+					t.Errorf("candidate %s keeps %q, want %q", candidate.InstanceID, candidate.KeptInstanceID, want)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidates = %v, want %v", got, tt.want)
+			}
+			if response.VaultCount != tt.wantVault {
+				t.Errorf("VaultCount = %d, want %d", response.VaultCount, tt.wantVault)
+			}
+		})
+	}
+}